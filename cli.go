@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"myapp/model"
+	"myapp/service"
+	"os"
+)
+
+// runCommand runs a command line tool instead of the server:
+//
+//	myapp export [-format jsonl|csv] [-user id] [-include-deleted] [-out file]
+//	myapp import [-format jsonl|csv] [-user id] [-in file]
+//	myapp timeline-rebuild
+func runCommand(args []string) error {
+	switch args[0] {
+	case "export":
+		return runExport(args[1:])
+	case "import":
+		return runImport(args[1:])
+	case "timeline-rebuild":
+		return runTimelineRebuild()
+	}
+
+	return fmt.Errorf("unknown command %q, expected export, import or timeline-rebuild", args[0])
+}
+
+func runExport(args []string) (err error) {
+	var (
+		filter model.PostExportFilter
+		out    string
+	)
+
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	flags.StringVar(&filter.Format, "format", model.PostExportJSONL, "jsonl or csv")
+	flags.IntVar(&filter.UserID, "user", 0, "only export posts of this user, 0 exports everyone")
+	flags.BoolVar(&filter.IncludeDeleted, "include-deleted", false, "include posts in the trash")
+	flags.StringVar(&out, "out", "", "output file, defaults to stdout")
+	flags.Parse(args)
+
+	var w io.Writer = os.Stdout
+	if out != "" {
+		file, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err = s.ErrorCheck(r)
+		}
+	}()
+
+	return s.PostExport(context.Background(), filter, w)
+}
+
+func runImport(args []string) error {
+	var (
+		format string
+		userID int
+		in     string
+	)
+
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	flags.StringVar(&format, "format", model.PostExportJSONL, "jsonl or csv")
+	flags.IntVar(&userID, "user", 0, "author of every imported post, 0 keeps the user_id of each row")
+	flags.StringVar(&in, "in", "", "input file, defaults to stdin")
+	flags.Parse(args)
+
+	var r io.Reader = os.Stdin
+	if in != "" {
+		file, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	result, err := service.PostImport(context.Background(), format, userID, r)
+	if result != nil {
+		encoder := json.NewEncoder(os.Stderr)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(result)
+	}
+
+	return err
+}
+
+// runTimelineRebuild fills timeline_entry from scratch, run it before
+// switching TIMELINE_STRATEGY to write.
+func runTimelineRebuild() (err error) {
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err = s.Rollback(r)
+		}
+	}()
+
+	count, _ := s.TimelineRebuild(context.Background())
+	if err := s.Commit(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "timeline-rebuild: %d entries\n", count)
+
+	return nil
+}
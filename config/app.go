@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetSystemUserID returns the user that owns content with no known author.
+func GetSystemUserID() int {
+	id, _ := strconv.Atoi(os.Getenv("SYSTEM_USER_ID"))
+	return id
+}
+
+// GetSearchBackend selects the post search implementation: "mysql" (default)
+// uses a FULLTEXT index, "memory" keeps an in-process inverted index on every
+// server instance, caught up with the others every SEARCH_REFRESH_INTERVAL.
+func GetSearchBackend() string {
+	backend := os.Getenv("SEARCH_BACKEND")
+	if backend == "" {
+		return "mysql"
+	}
+
+	return backend
+}
+
+// GetPublisherInterval is how often scheduled posts are checked, read from
+// PUBLISHER_INTERVAL as a Go duration (default 30s).
+func GetPublisherInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("PUBLISHER_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return 30 * time.Second
+	}
+
+	return interval
+}
+
+// GetOperatorUserIDs lists the users allowed to run operator actions such as
+// purging posts, read from OPERATOR_USER_IDS as a comma separated list.
+func GetOperatorUserIDs() []int {
+	var ids []int
+
+	for _, raw := range strings.Split(os.Getenv("OPERATOR_USER_IDS"), ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err == nil && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// GetTrashRetention is how long deleted posts can be restored before they
+// are purged, read from TRASH_RETENTION as a Go duration (default 720h).
+func GetTrashRetention() time.Duration {
+	retention, err := time.ParseDuration(os.Getenv("TRASH_RETENTION"))
+	if err != nil || retention <= 0 {
+		return 30 * 24 * time.Hour
+	}
+
+	return retention
+}
+
+// GetTrashPurgeInterval is how often expired posts are purged (default 1h).
+func GetTrashPurgeInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("TRASH_PURGE_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return time.Hour
+	}
+
+	return interval
+}
+
+// GetPostRequireIfMatch makes PUT and DELETE /post reject requests without an
+// If-Match header, read from POST_REQUIRE_IF_MATCH.
+func GetPostRequireIfMatch() bool {
+	require, _ := strconv.ParseBool(os.Getenv("POST_REQUIRE_IF_MATCH"))
+	return require
+}
+
+// GetTimelineStrategy selects how GET /timeline is built, read from
+// TIMELINE_STRATEGY: "read" (default) queries the posts of followed users on
+// every request, "write" copies new posts into each follower's timeline_entry
+// rows when they are published.
+func GetTimelineStrategy() string {
+	strategy := os.Getenv("TIMELINE_STRATEGY")
+	if strategy == "" {
+		return "read"
+	}
+
+	return strategy
+}
+
+// GetSearchRefreshInterval is how often the "memory" search backend applies
+// the posts other server instances indexed, read from SEARCH_REFRESH_INTERVAL
+// as a Go duration (default 5s).
+func GetSearchRefreshInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("SEARCH_REFRESH_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return 5 * time.Second
+	}
+
+	return interval
+}
+
+// GetNotificationPollInterval is how often notification streams look for new
+// events, read from NOTIFICATION_POLL_INTERVAL as a Go duration (default 1s).
+func GetNotificationPollInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("NOTIFICATION_POLL_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return time.Second
+	}
+
+	return interval
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GetAvatarSizes lists the square sizes in pixels generated for every avatar,
+// read from AVATAR_SIZES as a comma separated list (default 64,128,256).
+func GetAvatarSizes() []int {
+	var sizes []int
+
+	for _, raw := range strings.Split(os.Getenv("AVATAR_SIZES"), ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err == nil && size > 0 && size <= 2048 {
+			sizes = append(sizes, size)
+		}
+	}
+
+	if len(sizes) == 0 {
+		return []int{64, 128, 256}
+	}
+
+	sort.Ints(sizes)
+
+	return sizes
+}
+
+// GetAvatarMaxSize is the largest accepted avatar upload in bytes
+// (default 5 MiB).
+func GetAvatarMaxSize() int64 {
+	size, err := strconv.ParseInt(os.Getenv("AVATAR_MAX_SIZE"), 10, 64)
+	if err != nil || size <= 0 {
+		return 5 << 20
+	}
+
+	return size
+}
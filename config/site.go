@@ -0,0 +1,38 @@
+package config
+
+import (
+	"net/url"
+	"os"
+	"strings"
+)
+
+// GetPublicURL is the absolute URL clients reach the API at, read from
+// PUBLIC_URL (default http://localhost:<PORT>). Links handed out to other
+// systems, such as feed entries, are built from it.
+func GetPublicURL() *url.URL {
+	raw := strings.TrimRight(os.Getenv("PUBLIC_URL"), "/")
+	if raw == "" {
+		port := os.Getenv("PORT")
+		if port == "" {
+			port = "8080"
+		}
+		raw = "http://localhost:" + port
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		panic("invalid PUBLIC_URL " + raw)
+	}
+
+	return u
+}
+
+// GetSiteTitle names the site in feeds, read from SITE_TITLE.
+func GetSiteTitle() string {
+	title := os.Getenv("SITE_TITLE")
+	if title == "" {
+		return "Posting"
+	}
+
+	return title
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// GetStorageBackend selects where uploaded files are kept: "local" (default),
+// "s3" or "memory".
+func GetStorageBackend() string {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		return "local"
+	}
+
+	return backend
+}
+
+func GetStorageLocalDir() string {
+	dir := os.Getenv("STORAGE_LOCAL_DIR")
+	if dir == "" {
+		return "./uploads"
+	}
+
+	return dir
+}
+
+func GetS3Config() S3Config {
+	region := os.Getenv("S3_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return S3Config{
+		Endpoint:  os.Getenv("S3_ENDPOINT"),
+		Region:    region,
+		Bucket:    os.Getenv("S3_BUCKET"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+	}
+}
+
+// GetAttachmentMaxSize is the largest accepted upload in bytes (default 10 MiB).
+func GetAttachmentMaxSize() int64 {
+	size, err := strconv.ParseInt(os.Getenv("ATTACHMENT_MAX_SIZE"), 10, 64)
+	if err != nil || size <= 0 {
+		return 10 << 20
+	}
+
+	return size
+}
+
+// GetAttachmentURLTTL is how long a signed download URL stays valid
+// (default 15m).
+func GetAttachmentURLTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("ATTACHMENT_URL_TTL"))
+	if err != nil || ttl <= 0 {
+		return 15 * time.Minute
+	}
+
+	return ttl
+}
+
+// GetAttachmentURLKey signs download URLs, falling back to JWT_KEY.
+func GetAttachmentURLKey() string {
+	key := os.Getenv("ATTACHMENT_URL_KEY")
+	if key == "" {
+		return os.Getenv("JWT_KEY")
+	}
+
+	return key
+}
+
+// GetAttachmentSweepInterval is how often stored files no attachment uses
+// anymore are deleted, read from ATTACHMENT_SWEEP_INTERVAL (default 10m).
+func GetAttachmentSweepInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("ATTACHMENT_SWEEP_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return 10 * time.Minute
+	}
+
+	return interval
+}
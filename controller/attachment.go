@@ -0,0 +1,252 @@
+package controller
+
+import (
+	"io"
+	"mime"
+	"myapp/config"
+	"myapp/model"
+	"myapp/service"
+	"myapp/tools"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AttachmentCreate godoc
+// @Summary Upload attachment
+// @Description Upload a file to your post as multipart/form-data
+// @Tags Attachment
+// @Accept mpfd
+// @Produce json
+// @Param id query int true "Post id"
+// @Param file formData file true "File to upload"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.AttachmentResponse
+// @Failure 400 {object} model.AttachmentResponse
+// @Failure 403 {object} model.AttachmentResponse
+// @Failure 413 {object} model.AttachmentResponse
+// @Failure 415 {object} model.AttachmentResponse
+// @Failure 500 {object} model.AttachmentResponse
+// @Router /post/attachments [post]
+func AttachmentCreate(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.AttachmentResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	// Leave room for the multipart framing around the file itself.
+	maxSize := config.GetAttachmentMaxSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.AttachmentResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.AttachmentResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.AttachmentResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	attachment, _ := s.AttachmentCreate(c.Request.Context(), model.NewAttachment{
+		PostID:   postID,
+		FileName: header.Filename,
+		Data:     data,
+	})
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.AttachmentResponse{
+		Success: true,
+		Message: "Success",
+		Data:    attachment,
+	})
+}
+
+// AttachmentGetAll godoc
+// @Summary Get post attachments
+// @Description Get the attachments of a post with signed download URLs
+// @Tags Attachment
+// @Accept json
+// @Produce json
+// @Param id query int true "Post id"
+// @Param Authorization header string false "Bearer JWT token"
+// @Success 200 {object} model.AttachmentMultipleResponse
+// @Failure 400 {object} model.AttachmentMultipleResponse
+// @Failure 404 {object} model.AttachmentMultipleResponse
+// @Failure 500 {object} model.AttachmentMultipleResponse
+// @Router /post/attachments [get]
+func AttachmentGetAll(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.AttachmentMultipleResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.AttachmentMultipleResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	attachments, _ := s.AttachmentGetAllByPostID(c.Request.Context(), postID)
+
+	c.JSON(http.StatusOK, &model.AttachmentMultipleResponse{
+		Success: true,
+		Message: "Success",
+		Data:    attachments,
+	})
+}
+
+// AttachmentDownload godoc
+// @Summary Download attachment
+// @Description Download an attachment through a signed URL from the attachment list
+// @Tags Attachment
+// @Produce octet-stream
+// @Param id path int true "Attachment id"
+// @Param expires query int true "Expiry as unix time"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} file
+// @Failure 403 {object} model.GlobalResponse
+// @Failure 404 {object} model.GlobalResponse
+// @Failure 500 {object} model.GlobalResponse
+// @Router /attachments/{id}/download [get]
+func AttachmentDownload(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.GlobalResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.GlobalResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.GlobalResponse{
+					Success: false,
+					Message: message,
+				})
+				return
+			}
+		}
+	}()
+
+	attachment, _ := s.AttachmentGetSigned(c.Request.Context(), id, expires, c.Query("signature"))
+	body, _ := s.AttachmentOpen(c.Request.Context(), attachment)
+	defer body.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, body, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, max-age=" + strconv.FormatInt(expires-time.Now().Unix(), 10),
+	})
+}
+
+// AttachmentDelete godoc
+// @Summary Delete attachment
+// @Description Delete your own attachment
+// @Tags Attachment
+// @Accept json
+// @Produce json
+// @Param id query int true "Attachment id"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.GlobalResponse
+// @Failure 400 {object} model.GlobalResponse
+// @Failure 403 {object} model.GlobalResponse
+// @Failure 404 {object} model.GlobalResponse
+// @Failure 500 {object} model.GlobalResponse
+// @Router /attachment [delete]
+func AttachmentDelete(c *gin.Context) {
+	attachmentID, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.GlobalResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.GlobalResponse{
+					Success: false,
+					Message: message,
+				})
+				return
+			}
+		}
+	}()
+
+	resp, _ := s.AttachmentDeleteByID(c.Request.Context(), attachmentID)
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.GlobalResponse{
+		Success: true,
+		Message: resp,
+	})
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"myapp/model"
 	"myapp/service"
+	"myapp/tools"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -10,13 +11,14 @@ import (
 
 // AuthRefreshToken godoc
 // @Summary Get new access token
-// @Description Get new access token using refresh token
+// @Description Get a new access token and a new refresh token, the refresh token sent is revoked. Sending a refresh token that was already replaced revokes its whole session
 // @Tags Token
 // @Accept json
 // @Produce json
 // @Param body body model.RefreshTokenInput true "Refresh token data"
 // @Success 200 {object} model.TokenResponse
 // @Failure 400 {object} model.TokenResponse
+// @Failure 401 {object} model.TokenResponse
 // @Failure 500 {object} model.TokenResponse
 // @Router /auth/refresh [post]
 func AuthRefreshToken(c *gin.Context) {
@@ -39,9 +41,10 @@ func AuthRefreshToken(c *gin.Context) {
 		if r := recover(); r != nil {
 			err := s.Rollback(r)
 			if err != nil {
-				c.AbortWithStatusJSON(http.StatusInternalServerError, &model.TokenResponse{
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.TokenResponse{
 					Success: false,
-					Message: err.Error(),
+					Message: message,
 					Data:    nil,
 				})
 				return
@@ -49,8 +52,22 @@ func AuthRefreshToken(c *gin.Context) {
 		}
 	}()
 
-	data, _ := s.RefreshTokensGenerateAccessToken(c.Request.Context(), input.RefreshToken)
-	s.Commit()
+	// A reused refresh token comes back as an error after its family was
+	// revoked, the revocation is committed all the same.
+	data, err := s.RefreshTokensGenerateAccessToken(c.Request.Context(), input.RefreshToken)
+	if commitErr := s.Commit(); commitErr != nil {
+		panic(commitErr)
+	}
+
+	if err != nil {
+		code, message := tools.APIErrorResponse(err)
+		c.AbortWithStatusJSON(code, &model.TokenResponse{
+			Success: false,
+			Message: message,
+			Data:    nil,
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, &model.TokenResponse{
 		Success: true,
@@ -58,3 +75,64 @@ func AuthRefreshToken(c *gin.Context) {
 		Data:    data,
 	})
 }
+
+// AuthLogout godoc
+// @Summary Logout
+// @Description Revoke the access token of the request and the refresh token it was issued from
+// @Tags Token
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.GlobalResponse
+// @Failure 401 {object} model.GlobalResponse
+// @Failure 500 {object} model.GlobalResponse
+// @Router /auth/logout [post]
+func AuthLogout(c *gin.Context) {
+	authLogout(c, func(s *service.Service) string {
+		resp, _ := s.UserLogout(c.Request.Context())
+		return resp
+	})
+}
+
+// AuthLogoutAll godoc
+// @Summary Logout everywhere
+// @Description Revoke every access and refresh token of your account, on all devices
+// @Tags Token
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.GlobalResponse
+// @Failure 401 {object} model.GlobalResponse
+// @Failure 500 {object} model.GlobalResponse
+// @Router /auth/logout-all [post]
+func AuthLogoutAll(c *gin.Context) {
+	authLogout(c, func(s *service.Service) string {
+		resp, _ := s.UserLogoutAll(c.Request.Context())
+		return resp
+	})
+}
+
+func authLogout(c *gin.Context, logout func(s *service.Service) string) {
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.GlobalResponse{
+					Success: false,
+					Message: message,
+				})
+				return
+			}
+		}
+	}()
+
+	resp := logout(s)
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.GlobalResponse{
+		Success: true,
+		Message: resp,
+	})
+}
@@ -0,0 +1,313 @@
+package controller
+
+import (
+	"myapp/model"
+	"myapp/service"
+	"myapp/tools"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BookmarkPut godoc
+// @Summary Bookmark post
+// @Description Save a post for later, optionally into one of your folders. Bookmarking an already saved post moves it to the given folder
+// @Tags Bookmark
+// @Accept json
+// @Produce json
+// @Param body body model.NewBookmark true "Bookmark data, leave folder_id out to keep it out of any folder"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.BookmarkResponse
+// @Failure 400 {object} model.BookmarkResponse
+// @Failure 404 {object} model.BookmarkResponse
+// @Failure 500 {object} model.BookmarkResponse
+// @Router /post/bookmark [put]
+func BookmarkPut(c *gin.Context) {
+	var (
+		input model.NewBookmark
+	)
+
+	err := c.ShouldBind(&input)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.BookmarkResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.BookmarkResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	bookmark, _ := s.BookmarkPut(c.Request.Context(), input)
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.BookmarkResponse{
+		Success: true,
+		Message: "Success",
+		Data:    bookmark,
+	})
+}
+
+// BookmarkDelete godoc
+// @Summary Remove bookmark
+// @Description Remove a post from your bookmarks, repeating the request has no further effect
+// @Tags Bookmark
+// @Accept json
+// @Produce json
+// @Param id query int true "Post id"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.GlobalResponse
+// @Failure 400 {object} model.GlobalResponse
+// @Failure 500 {object} model.GlobalResponse
+// @Router /post/bookmark [delete]
+func BookmarkDelete(c *gin.Context) {
+	postIDStr := c.Query("id")
+
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.GlobalResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.GlobalResponse{
+					Success: false,
+					Message: message,
+				})
+				return
+			}
+		}
+	}()
+
+	resp, _ := s.BookmarkDelete(c.Request.Context(), postID)
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.GlobalResponse{
+		Success: true,
+		Message: resp,
+	})
+}
+
+// BookmarkGetAll godoc
+// @Summary List bookmarks
+// @Description List your bookmarked posts, most recently saved first
+// @Tags Bookmark
+// @Accept json
+// @Produce json
+// @Param folder_id query int false "Only bookmarks in this folder"
+// @Param unfiled query bool false "Only bookmarks in no folder"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.PostMultipleResponse
+// @Failure 400 {object} model.PostMultipleResponse
+// @Failure 404 {object} model.PostMultipleResponse
+// @Failure 500 {object} model.PostMultipleResponse
+// @Router /user/bookmarks [get]
+func BookmarkGetAll(c *gin.Context) {
+	var (
+		filter model.BookmarkFilter
+	)
+
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.PostMultipleResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.PostMultipleResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	page, _ := s.BookmarkGetAll(c.Request.Context(), filter)
+
+	for _, post := range page.Posts {
+		post.ContentHTML = ""
+	}
+
+	c.JSON(http.StatusOK, &model.PostMultipleResponse{
+		Success:    true,
+		Message:    "Success",
+		Data:       page.Posts,
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+	})
+}
+
+// BookmarkFolderCreate godoc
+// @Summary Create bookmark folder
+// @Description Create a named folder to group bookmarks into a reading list
+// @Tags Bookmark
+// @Accept json
+// @Produce json
+// @Param body body model.NewBookmarkFolder true "Folder data"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.BookmarkFolderResponse
+// @Failure 400 {object} model.BookmarkFolderResponse
+// @Failure 500 {object} model.BookmarkFolderResponse
+// @Router /user/bookmark-folder [post]
+func BookmarkFolderCreate(c *gin.Context) {
+	var (
+		input model.NewBookmarkFolder
+	)
+
+	err := c.ShouldBind(&input)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.BookmarkFolderResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.BookmarkFolderResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	folder, _ := s.BookmarkFolderCreate(c.Request.Context(), input)
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.BookmarkFolderResponse{
+		Success: true,
+		Message: "Success",
+		Data:    folder,
+	})
+}
+
+// BookmarkFolderGetAll godoc
+// @Summary List bookmark folders
+// @Description List your bookmark folders with the number of bookmarks in each
+// @Tags Bookmark
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.BookmarkFolderMultipleResponse
+// @Failure 500 {object} model.BookmarkFolderMultipleResponse
+// @Router /user/bookmark-folders [get]
+func BookmarkFolderGetAll(c *gin.Context) {
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.BookmarkFolderMultipleResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	folders, _ := s.BookmarkFolderGetAll(c.Request.Context())
+
+	c.JSON(http.StatusOK, &model.BookmarkFolderMultipleResponse{
+		Success: true,
+		Message: "Success",
+		Data:    folders,
+	})
+}
+
+// BookmarkFolderDelete godoc
+// @Summary Delete bookmark folder
+// @Description Delete one of your bookmark folders, the bookmarks in it are kept outside of any folder
+// @Tags Bookmark
+// @Accept json
+// @Produce json
+// @Param id query int true "Folder id"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.GlobalResponse
+// @Failure 400 {object} model.GlobalResponse
+// @Failure 404 {object} model.GlobalResponse
+// @Failure 500 {object} model.GlobalResponse
+// @Router /user/bookmark-folder [delete]
+func BookmarkFolderDelete(c *gin.Context) {
+	folderIDStr := c.Query("id")
+
+	folderID, err := strconv.Atoi(folderIDStr)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.GlobalResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.GlobalResponse{
+					Success: false,
+					Message: message,
+				})
+				return
+			}
+		}
+	}()
+
+	resp, _ := s.BookmarkFolderDeleteByID(c.Request.Context(), folderID)
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.GlobalResponse{
+		Success: true,
+		Message: resp,
+	})
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"myapp/model"
+	"myapp/service"
+	"myapp/tools"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CategoryCreate godoc
+// @Summary Create category
+// @Description Create category, posts reference it by slug. Operators only.
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Param body body model.NewCategory true "Category data"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.CategoryResponse
+// @Failure 400 {object} model.CategoryResponse
+// @Failure 403 {object} model.CategoryResponse
+// @Failure 500 {object} model.CategoryResponse
+// @Router /category [post]
+func CategoryCreate(c *gin.Context) {
+	var (
+		input model.NewCategory
+	)
+
+	err := c.ShouldBind(&input)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.CategoryResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.CategoryResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	category, _ := s.CategoryCreate(c.Request.Context(), input)
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.CategoryResponse{
+		Success: true,
+		Message: "Success",
+		Data:    category,
+	})
+}
+
+// CategoryGetAll godoc
+// @Summary Get all categories
+// @Description Get all categories with the number of published posts in them
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.CategoryMultipleResponse
+// @Failure 500 {object} model.CategoryMultipleResponse
+// @Router /categories [get]
+func CategoryGetAll(c *gin.Context) {
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.CategoryMultipleResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	categories, _ := s.CategoryGetAll(c.Request.Context())
+
+	c.JSON(http.StatusOK, &model.CategoryMultipleResponse{
+		Success: true,
+		Message: "Success",
+		Data:    categories,
+	})
+}
@@ -0,0 +1,284 @@
+package controller
+
+import (
+	"myapp/model"
+	"myapp/service"
+	"myapp/tools"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CommentCreate godoc
+// @Summary Create comment
+// @Description Comment on a post, set parent_id to reply to another comment
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Param body body model.NewComment true "Comment data"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.CommentResponse
+// @Failure 400 {object} model.CommentResponse
+// @Failure 404 {object} model.CommentResponse
+// @Failure 500 {object} model.CommentResponse
+// @Router /comment [post]
+func CommentCreate(c *gin.Context) {
+	var (
+		input model.NewComment
+	)
+
+	err := c.ShouldBind(&input)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.CommentResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.CommentResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	comment, _ := s.CommentCreate(c.Request.Context(), input)
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.CommentResponse{
+		Success: true,
+		Message: "Success",
+		Data:    comment,
+	})
+}
+
+// CommentUpdate godoc
+// @Summary Update comment
+// @Description Edit your own comment
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Param body body model.UpdateComment true "Comment data"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.CommentResponse
+// @Failure 400 {object} model.CommentResponse
+// @Failure 403 {object} model.CommentResponse
+// @Failure 404 {object} model.CommentResponse
+// @Failure 500 {object} model.CommentResponse
+// @Router /comment [put]
+func CommentUpdate(c *gin.Context) {
+	var (
+		input model.UpdateComment
+	)
+
+	err := c.ShouldBind(&input)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.CommentResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.CommentResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	comment, _ := s.CommentUpdate(c.Request.Context(), input)
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.CommentResponse{
+		Success: true,
+		Message: "Success",
+		Data:    comment,
+	})
+}
+
+// CommentDelete godoc
+// @Summary Delete comment
+// @Description Delete your own comment, or any comment on your post
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Param id query int true "Comment id"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.GlobalResponse
+// @Failure 400 {object} model.GlobalResponse
+// @Failure 403 {object} model.GlobalResponse
+// @Failure 404 {object} model.GlobalResponse
+// @Failure 500 {object} model.GlobalResponse
+// @Router /comment [delete]
+func CommentDelete(c *gin.Context) {
+	commentIDStr := c.Query("id")
+
+	commentID, err := strconv.Atoi(commentIDStr)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.GlobalResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.GlobalResponse{
+					Success: false,
+					Message: message,
+				})
+				return
+			}
+		}
+	}()
+
+	resp, _ := s.CommentDeleteByID(c.Request.Context(), commentID)
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.GlobalResponse{
+		Success: true,
+		Message: resp,
+	})
+}
+
+// CommentGetAll godoc
+// @Summary Get post comments
+// @Description Get the comments of a post as a flat list, oldest first
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Param id query int true "Post id"
+// @Param cursor query string false "Cursor from a previous next_cursor"
+// @Param limit query int false "Page size (default 50, max 100)"
+// @Param Authorization header string false "Bearer JWT token"
+// @Success 200 {object} model.CommentMultipleResponse
+// @Failure 400 {object} model.CommentMultipleResponse
+// @Failure 404 {object} model.CommentMultipleResponse
+// @Failure 500 {object} model.CommentMultipleResponse
+// @Router /post/comments [get]
+func CommentGetAll(c *gin.Context) {
+	var (
+		filter model.CommentFilter
+	)
+
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.CommentMultipleResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.CommentMultipleResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	page, _ := s.CommentGetAllByPostID(c.Request.Context(), filter)
+
+	c.JSON(http.StatusOK, &model.CommentMultipleResponse{
+		Success:    true,
+		Message:    "Success",
+		Data:       page.Comments,
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+	})
+}
+
+// CommentGetTree godoc
+// @Summary Get post comment tree
+// @Description Get the comments of a post as a tree, replies are nested under their parent. Top level comments are paginated oldest first, at most 1000 replies are included per page
+// @Tags Comment
+// @Accept json
+// @Produce json
+// @Param id query int true "Post id"
+// @Param cursor query string false "Cursor from a previous next_cursor"
+// @Param limit query int false "Top level comments per page (default 50, max 100)"
+// @Param Authorization header string false "Bearer JWT token"
+// @Success 200 {object} model.CommentMultipleResponse
+// @Failure 400 {object} model.CommentMultipleResponse
+// @Failure 404 {object} model.CommentMultipleResponse
+// @Failure 500 {object} model.CommentMultipleResponse
+// @Router /post/comments/tree [get]
+func CommentGetTree(c *gin.Context) {
+	var (
+		filter model.CommentFilter
+	)
+
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.CommentMultipleResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.CommentMultipleResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	page, _ := s.CommentGetTreeByPostID(c.Request.Context(), filter)
+
+	c.JSON(http.StatusOK, &model.CommentMultipleResponse{
+		Success:    true,
+		Message:    "Success",
+		Data:       page.Comments,
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+	})
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"myapp/model"
+	"myapp/service"
+	"myapp/tools"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var feedRenderers = map[string]func(*model.Feed) ([]byte, error){
+	model.FeedFormatRSS:  tools.RenderRSS,
+	model.FeedFormatAtom: tools.RenderAtom,
+	model.FeedFormatJSON: tools.RenderJSONFeed,
+}
+
+var feedContentTypes = map[string]string{
+	model.FeedFormatRSS:  "application/rss+xml; charset=utf-8",
+	model.FeedFormatAtom: "application/atom+xml; charset=utf-8",
+	model.FeedFormatJSON: "application/feed+json; charset=utf-8",
+}
+
+// FeedRSS godoc
+// @Summary RSS feed
+// @Description Latest published posts as RSS 2.0. Supports If-None-Match and If-Modified-Since.
+// @Tags Feed
+// @Produce xml
+// @Success 200 {string} string "RSS document"
+// @Success 304 "Not modified"
+// @Failure 500 {object} model.GlobalResponse
+// @Router /feed.rss [get]
+func FeedRSS(c *gin.Context) {
+	feedServe(c, model.FeedFormatRSS)
+}
+
+// FeedAtom godoc
+// @Summary Atom feed
+// @Description Latest published posts as Atom 1.0. Supports If-None-Match and If-Modified-Since.
+// @Tags Feed
+// @Produce xml
+// @Success 200 {string} string "Atom document"
+// @Success 304 "Not modified"
+// @Failure 500 {object} model.GlobalResponse
+// @Router /feed.atom [get]
+func FeedAtom(c *gin.Context) {
+	feedServe(c, model.FeedFormatAtom)
+}
+
+// FeedJSON godoc
+// @Summary JSON feed
+// @Description Latest published posts as JSON Feed 1.1. Supports If-None-Match and If-Modified-Since.
+// @Tags Feed
+// @Produce json
+// @Success 200 {string} string "JSON Feed document"
+// @Success 304 "Not modified"
+// @Failure 500 {object} model.GlobalResponse
+// @Router /feed.json [get]
+func FeedJSON(c *gin.Context) {
+	feedServe(c, model.FeedFormatJSON)
+}
+
+// feedServe renders a feed and answers conditional requests with 304. The
+// ETag is a hash of the rendered document, Last-Modified is the newest entry.
+func feedServe(c *gin.Context, format string) {
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.GlobalResponse{
+					Success: false,
+					Message: message,
+				})
+				return
+			}
+		}
+	}()
+
+	feed, _ := s.PostFeed(c.Request.Context(), format)
+
+	body, err := feedRenderers[format](feed)
+	if err != nil {
+		panic(err)
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=60")
+	if feed.Updated != nil {
+		c.Header("Last-Modified", feed.Updated.UTC().Format(http.TimeFormat))
+	}
+
+	if feedNotModified(c, etag, feed.Updated) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, feedContentTypes[format], body)
+}
+
+// feedNotModified follows RFC 9110: If-None-Match wins when present,
+// If-Modified-Since is only looked at without it.
+func feedNotModified(c *gin.Context, etag string, updated *time.Time) bool {
+	if ifNoneMatch := tools.ParseETagList(c.GetHeader("If-None-Match")); ifNoneMatch != nil {
+		return tools.ETagMatchWeak(ifNoneMatch, etag)
+	}
+
+	since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+	if err != nil || updated == nil {
+		return false
+	}
+
+	return !updated.Truncate(time.Second).After(since)
+}
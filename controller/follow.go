@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"myapp/model"
+	"myapp/service"
+	"myapp/tools"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FollowCreate godoc
+// @Summary Follow user
+// @Description Follow a user, their posts show up in your timeline
+// @Tags Follow
+// @Accept json
+// @Produce json
+// @Param id path int true "User id"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.FollowStatsResponse
+// @Failure 400 {object} model.FollowStatsResponse
+// @Failure 404 {object} model.FollowStatsResponse
+// @Failure 500 {object} model.FollowStatsResponse
+// @Router /users/{id}/follow [put]
+func FollowCreate(c *gin.Context) {
+	followWrite(c, func(s *service.Service, userID int) *model.FollowStats {
+		stats, _ := s.FollowCreate(c.Request.Context(), userID)
+		return stats
+	})
+}
+
+// FollowDelete godoc
+// @Summary Unfollow user
+// @Description Stop following a user
+// @Tags Follow
+// @Accept json
+// @Produce json
+// @Param id path int true "User id"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.FollowStatsResponse
+// @Failure 400 {object} model.FollowStatsResponse
+// @Failure 404 {object} model.FollowStatsResponse
+// @Failure 500 {object} model.FollowStatsResponse
+// @Router /users/{id}/follow [delete]
+func FollowDelete(c *gin.Context) {
+	followWrite(c, func(s *service.Service, userID int) *model.FollowStats {
+		stats, _ := s.FollowDelete(c.Request.Context(), userID)
+		return stats
+	})
+}
+
+func followWrite(c *gin.Context, write func(s *service.Service, userID int) *model.FollowStats) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.FollowStatsResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.FollowStatsResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	stats := write(s, userID)
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.FollowStatsResponse{
+		Success: true,
+		Message: "Success",
+		Data:    stats,
+	})
+}
+
+// FollowStats godoc
+// @Summary Get follow counts
+// @Description Get follower and following counts of a user, and whether you follow them
+// @Tags Follow
+// @Accept json
+// @Produce json
+// @Param id path int true "User id"
+// @Param Authorization header string false "Bearer JWT token, fills followed_by_me"
+// @Success 200 {object} model.FollowStatsResponse
+// @Failure 400 {object} model.FollowStatsResponse
+// @Failure 404 {object} model.FollowStatsResponse
+// @Failure 500 {object} model.FollowStatsResponse
+// @Router /users/{id}/follow [get]
+func FollowStats(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.FollowStatsResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.FollowStatsResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	stats, _ := s.FollowStats(c.Request.Context(), userID)
+
+	c.JSON(http.StatusOK, &model.FollowStatsResponse{
+		Success: true,
+		Message: "Success",
+		Data:    stats,
+	})
+}
+
+// FollowGetFollowers godoc
+// @Summary List followers
+// @Description List the users following a user, most recent first
+// @Tags Follow
+// @Accept json
+// @Produce json
+// @Param id path int true "User id"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} model.FollowUserMultipleResponse
+// @Failure 400 {object} model.FollowUserMultipleResponse
+// @Failure 404 {object} model.FollowUserMultipleResponse
+// @Failure 500 {object} model.FollowUserMultipleResponse
+// @Router /users/{id}/followers [get]
+func FollowGetFollowers(c *gin.Context) {
+	followList(c, func(s *service.Service, userID int, filter model.FollowFilter) *model.FollowUserPage {
+		page, _ := s.FollowGetFollowers(c.Request.Context(), userID, filter)
+		return page
+	})
+}
+
+// FollowGetFollowing godoc
+// @Summary List following
+// @Description List the users a user follows, most recent first
+// @Tags Follow
+// @Accept json
+// @Produce json
+// @Param id path int true "User id"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} model.FollowUserMultipleResponse
+// @Failure 400 {object} model.FollowUserMultipleResponse
+// @Failure 404 {object} model.FollowUserMultipleResponse
+// @Failure 500 {object} model.FollowUserMultipleResponse
+// @Router /users/{id}/following [get]
+func FollowGetFollowing(c *gin.Context) {
+	followList(c, func(s *service.Service, userID int, filter model.FollowFilter) *model.FollowUserPage {
+		page, _ := s.FollowGetFollowing(c.Request.Context(), userID, filter)
+		return page
+	})
+}
+
+func followList(c *gin.Context, list func(s *service.Service, userID int, filter model.FollowFilter) *model.FollowUserPage) {
+	var (
+		filter model.FollowFilter
+	)
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err == nil {
+		err = c.ShouldBindQuery(&filter)
+	}
+
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.FollowUserMultipleResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.FollowUserMultipleResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	page := list(s, userID, filter)
+
+	c.JSON(http.StatusOK, &model.FollowUserMultipleResponse{
+		Success:    true,
+		Message:    "Success",
+		Data:       page.Users,
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+	})
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"myapp/model"
+	"myapp/service"
+	"myapp/tools"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MentionGetAll godoc
+// @Summary List mentions
+// @Description List posts that @mention you, most recently mentioned first
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.PostMultipleResponse
+// @Failure 400 {object} model.PostMultipleResponse
+// @Failure 500 {object} model.PostMultipleResponse
+// @Router /user/mentions [get]
+func MentionGetAll(c *gin.Context) {
+	var (
+		filter model.MentionFilter
+	)
+
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.PostMultipleResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.PostMultipleResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	page, _ := s.MentionGetAll(c.Request.Context(), filter)
+
+	for _, post := range page.Posts {
+		post.ContentHTML = ""
+	}
+
+	c.JSON(http.StatusOK, &model.PostMultipleResponse{
+		Success:    true,
+		Message:    "Success",
+		Data:       page.Posts,
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+	})
+}
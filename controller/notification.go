@@ -0,0 +1,303 @@
+package controller
+
+import (
+	"io"
+	"myapp/model"
+	"myapp/service"
+	"myapp/tools"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// notificationHeartbeat is how often an idle stream sends a comment, so
+// proxies do not close the connection.
+const notificationHeartbeat = 25 * time.Second
+
+// NotificationGetAll godoc
+// @Summary List notifications
+// @Description List your notifications, newest first
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Param unread query bool false "Only unread notifications"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size (default 50, max 100)"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.NotificationMultipleResponse
+// @Failure 400 {object} model.NotificationMultipleResponse
+// @Failure 500 {object} model.NotificationMultipleResponse
+// @Router /notifications [get]
+func NotificationGetAll(c *gin.Context) {
+	var (
+		filter model.NotificationFilter
+	)
+
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.NotificationMultipleResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.NotificationMultipleResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	page, _ := s.NotificationGetAll(c.Request.Context(), filter)
+
+	c.JSON(http.StatusOK, &model.NotificationMultipleResponse{
+		Success:    true,
+		Message:    "Success",
+		Data:       page.Notifications,
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+	})
+}
+
+// NotificationUnread godoc
+// @Summary Unread notification count
+// @Description Get how many of your notifications are unread
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.NotificationUnreadResponse
+// @Failure 500 {object} model.NotificationUnreadResponse
+// @Router /notifications/unread [get]
+func NotificationUnread(c *gin.Context) {
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.NotificationUnreadResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	unread, _ := s.NotificationUnread(c.Request.Context())
+
+	c.JSON(http.StatusOK, &model.NotificationUnreadResponse{
+		Success: true,
+		Message: "Success",
+		Data:    unread,
+	})
+}
+
+// NotificationMarkRead godoc
+// @Summary Mark notification read
+// @Description Mark one of your notifications as read, returns the new unread count
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Param id query int true "Notification id"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.NotificationUnreadResponse
+// @Failure 400 {object} model.NotificationUnreadResponse
+// @Failure 404 {object} model.NotificationUnreadResponse
+// @Failure 500 {object} model.NotificationUnreadResponse
+// @Router /notifications/read [post]
+func NotificationMarkRead(c *gin.Context) {
+	notificationIDStr := c.Query("id")
+
+	notificationID, err := strconv.Atoi(notificationIDStr)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.NotificationUnreadResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	notificationWrite(c, func(s *service.Service) *model.NotificationUnread {
+		unread, _ := s.NotificationMarkRead(c.Request.Context(), notificationID)
+		return unread
+	})
+}
+
+// NotificationMarkAllRead godoc
+// @Summary Mark all notifications read
+// @Description Mark all of your notifications as read
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.NotificationUnreadResponse
+// @Failure 500 {object} model.NotificationUnreadResponse
+// @Router /notifications/read-all [post]
+func NotificationMarkAllRead(c *gin.Context) {
+	notificationWrite(c, func(s *service.Service) *model.NotificationUnread {
+		unread, _ := s.NotificationMarkAllRead(c.Request.Context())
+		return unread
+	})
+}
+
+func notificationWrite(c *gin.Context, write func(s *service.Service) *model.NotificationUnread) {
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.NotificationUnreadResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	unread := write(s)
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.NotificationUnreadResponse{
+		Success: true,
+		Message: "Success",
+		Data:    unread,
+	})
+}
+
+// NotificationStream godoc
+// @Summary Notification stream
+// @Description Server-Sent Events stream of your notifications. A "notification" event carries a new notification and its id, an "unread" event the unread count, sent on connect and whenever it changes. The stream ends when the access token is revoked or expires. Reconnect with Last-Event-ID to receive the notifications missed in between
+// @Tags Notification
+// @Produce text/event-stream
+// @Param Authorization header string true "Bearer JWT token"
+// @Param Last-Event-ID header int false "Id of the last notification received"
+// @Success 200 {string} string "Event stream"
+// @Failure 500 {object} model.GlobalResponse
+// @Router /notifications/stream [get]
+func NotificationStream(c *gin.Context) {
+	var (
+		ctx       = c.Request.Context()
+		lastID, _ = strconv.Atoi(c.GetHeader("Last-Event-ID"))
+	)
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.GlobalResponse{
+					Success: false,
+					Message: message,
+				})
+				return
+			}
+		}
+	}()
+
+	// Subscribe before reading what was missed, so nothing committed in
+	// between is lost. The hub sends every notification once, only the
+	// replayed ones can come again and are skipped by id. A notification
+	// with a lower id than one already sent is not a duplicate, its
+	// transaction committed later.
+	events, unsubscribe := s.NotificationSubscribe(ctx)
+	defer unsubscribe()
+
+	var (
+		missed   []*model.Notification
+		replayed = map[int]bool{}
+	)
+
+	if lastID > 0 {
+		missed, _ = s.NotificationGetSince(ctx, lastID)
+	}
+
+	unread, _ := s.NotificationUnread(ctx)
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, notification := range missed {
+		replayed[notification.ID] = true
+		lastID = notificationStreamSend(c, lastID, nil, &service.NotificationEvent{
+			Name:         "notification",
+			Notification: notification,
+		})
+	}
+
+	notificationStreamSend(c, lastID, nil, &service.NotificationEvent{
+		Name:   "unread",
+		Unread: unread,
+	})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(notificationHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case event, ok := <-events:
+			// Closed once the access token is revoked or expires.
+			if !ok {
+				return false
+			}
+			lastID = notificationStreamSend(c, lastID, replayed, event)
+		case <-heartbeat.C:
+			io.WriteString(w, ": ping\n\n")
+		}
+
+		return true
+	})
+}
+
+// notificationStreamSend writes event unless it is a notification in skip,
+// and returns the highest notification id sent. That is the event id, so a
+// reconnect replays from after it even when a lower id arrived last.
+func notificationStreamSend(c *gin.Context, lastID int, skip map[int]bool, event *service.NotificationEvent) int {
+	if event.Notification == nil {
+		c.Render(-1, sse.Event{
+			Event: event.Name,
+			Data:  event.Unread,
+		})
+
+		return lastID
+	}
+
+	if skip[event.Notification.ID] {
+		return lastID
+	}
+
+	lastID = max(lastID, event.Notification.ID)
+
+	c.Render(-1, sse.Event{
+		Id:    strconv.Itoa(lastID),
+		Event: event.Name,
+		Data:  event.Notification,
+	})
+
+	return lastID
+}
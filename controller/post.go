@@ -1,10 +1,12 @@
 package controller
 
 import (
+	"myapp/config"
 	"myapp/model"
 	"myapp/service"
 	"myapp/tools"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -56,6 +58,7 @@ func PostCreate(c *gin.Context) {
 	post, _ := s.PostCreate(c.Request.Context(), input)
 	s.Commit()
 
+	c.Header("ETag", tools.PostETag(post.ID, post.Version))
 	c.JSON(http.StatusOK, &model.PostResponse{
 		Success: true,
 		Message: "Success",
@@ -71,8 +74,13 @@ func PostCreate(c *gin.Context) {
 // @Produce json
 // @Param body body model.UpdatePost true "Post data"
 // @Param Authorization header string true "Bearer JWT token"
+// @Param If-Match header string false "ETag from GET /post, the update fails with 412 if the post changed since"
 // @Success 200 {object} model.PostResponse
 // @Failure 400 {object} model.PostResponse
+// @Failure 403 {object} model.PostResponse
+// @Failure 404 {object} model.PostResponse
+// @Failure 412 {object} model.PostResponse
+// @Failure 428 {object} model.PostResponse
 // @Failure 500 {object} model.PostResponse
 // @Router /post [put]
 func PostUpdate(c *gin.Context) {
@@ -90,24 +98,95 @@ func PostUpdate(c *gin.Context) {
 		return
 	}
 
+	ifMatch, ok := postIfMatch(c)
+	if !ok {
+		return
+	}
+
 	s := service.GetTransaction()
 	defer func() {
 		if r := recover(); r != nil {
 			err := s.Rollback(r)
 			if err != nil {
-				c.AbortWithStatusJSON(http.StatusInternalServerError, &model.PostResponse{
-					Success: false,
-					Message: err.Error(),
-					Data:    nil,
-				})
+				postAbortError(c, err)
+				return
+			}
+		}
+	}()
+
+	post, _ := s.PostUpdate(c.Request.Context(), input, ifMatch)
+	s.Commit()
+
+	c.Header("ETag", tools.PostETag(post.ID, post.Version))
+	c.JSON(http.StatusOK, &model.PostResponse{
+		Success: true,
+		Message: "Success",
+		Data:    post,
+	})
+}
+
+// PostPatch godoc
+// @Summary Patch post
+// @Description Partially update a post with a JSON Merge Patch (application/merge-patch+json) or a JSON Patch (application/json-patch+json). Patches apply to {title, content, content_format, status, visibility, publish_at, tags, categories}, where tags are names and categories are slugs. A null publish_at clears it on a draft or archived post, a scheduled post needs a future one and a published post keeps its publish time.
+// @Tags Post
+// @Accept json
+// @Produce json
+// @Param id path int true "Post id"
+// @Param body body model.PostPatchDocument true "Merge patch, or an array of JSON Patch operations"
+// @Param Authorization header string true "Bearer JWT token"
+// @Param If-Match header string false "ETag from GET /post, the patch fails with 412 if the post changed since"
+// @Success 200 {object} model.PostResponse
+// @Failure 400 {object} model.PostResponse
+// @Failure 403 {object} model.PostResponse
+// @Failure 404 {object} model.PostResponse
+// @Failure 409 {object} model.PostResponse
+// @Failure 412 {object} model.PostResponse
+// @Failure 415 {object} model.PostResponse
+// @Failure 422 {object} model.PostResponse
+// @Failure 428 {object} model.PostResponse
+// @Failure 500 {object} model.PostResponse
+// @Router /post/{id} [patch]
+func PostPatch(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.PostResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.PostResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	ifMatch, ok := postIfMatch(c)
+	if !ok {
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				postAbortError(c, err)
 				return
 			}
 		}
 	}()
 
-	post, _ := s.PostUpdate(c.Request.Context(), input)
+	post, _ := s.PostPatch(c.Request.Context(), postID, c.ContentType(), patch, ifMatch)
 	s.Commit()
 
+	c.Header("ETag", tools.PostETag(post.ID, post.Version))
 	c.JSON(http.StatusOK, &model.PostResponse{
 		Success: true,
 		Message: "Success",
@@ -123,8 +202,13 @@ func PostUpdate(c *gin.Context) {
 // @Produce json
 // @Param id query int true "Post id"
 // @Param Authorization header string true "Bearer JWT token"
+// @Param If-Match header string false "ETag from GET /post, the delete fails with 412 if the post changed since"
 // @Success 200 {object} model.GlobalResponse
 // @Failure 400 {object} model.GlobalResponse
+// @Failure 403 {object} model.GlobalResponse
+// @Failure 404 {object} model.GlobalResponse
+// @Failure 412 {object} model.PostResponse
+// @Failure 428 {object} model.GlobalResponse
 // @Failure 500 {object} model.GlobalResponse
 // @Router /post [delete]
 func PostDelete(c *gin.Context) {
@@ -139,24 +223,26 @@ func PostDelete(c *gin.Context) {
 		return
 	}
 
+	ifMatch, ok := postIfMatch(c)
+	if !ok {
+		return
+	}
+
 	s := service.GetTransaction()
 	defer func() {
 		if r := recover(); r != nil {
 			err := s.Rollback(r)
 			if err != nil {
-				c.AbortWithStatusJSON(http.StatusInternalServerError, &model.GlobalResponse{
-					Success: false,
-					Message: err.Error(),
-				})
+				postAbortError(c, err)
 				return
 			}
 		}
 	}()
 
-	resp, _ := s.PostDeleteByID(c.Request.Context(), postID)
+	resp, _ := s.PostDeleteByID(c.Request.Context(), postID, ifMatch)
 	s.Commit()
 
-	c.JSON(http.StatusInternalServerError, &model.GlobalResponse{
+	c.JSON(http.StatusOK, &model.GlobalResponse{
 		Success: true,
 		Message: resp,
 	})
@@ -164,22 +250,49 @@ func PostDelete(c *gin.Context) {
 
 // PostGetAll godoc
 // @Summary Get all posts
-// @Description Get all posts from all user
+// @Description Get posts from all user, paginated with an opaque cursor
 // @Tags Post
 // @Accept json
 // @Produce json
+// @Param cursor query string false "Cursor from a previous next_cursor"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param sort query string false "Sort field" Enums(created_at, updated_at, title)
+// @Param order query string false "Sort order" Enums(asc, desc)
+// @Param title query string false "Title prefix"
+// @Param html query bool false "Include content_html"
+// @Param tag query string false "Tag slug"
+// @Param category query string false "Category slug"
+// @Param created_before query string false "RFC3339 timestamp"
+// @Param created_after query string false "RFC3339 timestamp"
+// @Param Authorization header string false "Bearer JWT token, includes your unpublished posts"
 // @Success 200 {object} model.PostMultipleResponse
+// @Failure 400 {object} model.PostMultipleResponse
 // @Failure 500 {object} model.PostMultipleResponse
 // @Router /posts [get]
 func PostGetAll(c *gin.Context) {
+	var (
+		filter model.PostFilter
+	)
+
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.PostMultipleResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
 	s := service.GetService()
 	defer func() {
 		if r := recover(); r != nil {
 			err := s.ErrorCheck(r)
 			if err != nil {
-				c.AbortWithStatusJSON(http.StatusInternalServerError, &model.PostMultipleResponse{
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.PostMultipleResponse{
 					Success: false,
-					Message: err.Error(),
+					Message: message,
 					Data:    nil,
 				})
 				return
@@ -187,12 +300,20 @@ func PostGetAll(c *gin.Context) {
 		}
 	}()
 
-	posts, _ := s.PostGetAll(c.Request.Context())
+	page, _ := s.PostGetAll(c.Request.Context(), filter)
+
+	if !filter.HTML {
+		for _, post := range page.Posts {
+			post.ContentHTML = ""
+		}
+	}
 
 	c.JSON(http.StatusOK, &model.PostMultipleResponse{
-		Success: true,
-		Message: "Success",
-		Data:    posts,
+		Success:    true,
+		Message:    "Success",
+		Data:       page.Posts,
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
 	})
 }
 
@@ -203,8 +324,11 @@ func PostGetAll(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id query int true "Post id"
-// @Success 200 {object} model.PostResponse
+// @Param html query bool false "Include content_html"
+// @Param Authorization header string false "Bearer JWT token, allows reading your unpublished posts"
+// @Success 200 {object} model.PostResponse "ETag header holds the version to send back in If-Match"
 // @Success 400 {object} model.PostResponse
+// @Failure 404 {object} model.PostResponse
 // @Failure 500 {object} model.PostResponse
 // @Router /post [get]
 func PostGetByID(c *gin.Context) {
@@ -225,9 +349,10 @@ func PostGetByID(c *gin.Context) {
 		if r := recover(); r != nil {
 			err := s.ErrorCheck(r)
 			if err != nil {
-				c.AbortWithStatusJSON(http.StatusInternalServerError, &model.PostResponse{
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.PostResponse{
 					Success: false,
-					Message: err.Error(),
+					Message: message,
 					Data:    nil,
 				})
 				return
@@ -237,9 +362,168 @@ func PostGetByID(c *gin.Context) {
 
 	post, _ := s.PostGetByID(c.Request.Context(), postID)
 
+	if c.Query("html") != "true" {
+		post.ContentHTML = ""
+	}
+
+	c.Header("ETag", tools.PostETag(post.ID, post.Version))
+	c.JSON(http.StatusOK, &model.PostResponse{
+		Success: true,
+		Message: "Success",
+		Data:    post,
+	})
+}
+
+// PostGetBySlug godoc
+// @Summary Get post by slug
+// @Description Get post by its permalink slug, slugs from before a title change redirect to the current one
+// @Tags Post
+// @Accept json
+// @Produce json
+// @Param slug path string true "Post slug"
+// @Param html query bool false "Include content_html"
+// @Param Authorization header string false "Bearer JWT token, allows reading your unpublished posts"
+// @Success 200 {object} model.PostResponse
+// @Success 301 "Moved to the current slug"
+// @Failure 404 {object} model.PostResponse
+// @Failure 500 {object} model.PostResponse
+// @Router /posts/by-slug/{slug} [get]
+func PostGetBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.PostResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	current, _ := s.PostResolveSlug(c.Request.Context(), slug)
+	if current != slug {
+		location := "/posts/by-slug/" + url.PathEscape(current)
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+
+		c.Redirect(http.StatusMovedPermanently, location)
+		return
+	}
+
+	post, _ := s.PostGetBySlug(c.Request.Context(), slug)
+
+	if c.Query("html") != "true" {
+		post.ContentHTML = ""
+	}
+
 	c.JSON(http.StatusOK, &model.PostResponse{
 		Success: true,
 		Message: "Success",
 		Data:    post,
 	})
 }
+
+// PostSearch godoc
+// @Summary Search posts
+// @Description Full-text search over post titles and content, ranked by relevance
+// @Tags Post
+// @Accept json
+// @Produce json
+// @Param q query string true "Search query"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param offset query int false "Number of results to skip"
+// @Param Authorization header string false "Bearer JWT token, includes your unpublished posts"
+// @Success 200 {object} model.PostSearchResponse
+// @Failure 400 {object} model.PostSearchResponse
+// @Failure 500 {object} model.PostSearchResponse
+// @Router /posts/search [get]
+func PostSearch(c *gin.Context) {
+	var (
+		input model.PostSearchInput
+	)
+
+	err := c.ShouldBindQuery(&input)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.PostSearchResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.PostSearchResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	hits, _ := s.PostSearch(c.Request.Context(), input)
+
+	for _, hit := range hits {
+		hit.Post.ContentHTML = ""
+	}
+
+	c.JSON(http.StatusOK, &model.PostSearchResponse{
+		Success: true,
+		Message: "Success",
+		Data:    hits,
+	})
+}
+
+// postIfMatch reads the If-Match header of a post write. It aborts with 428
+// when POST_REQUIRE_IF_MATCH is set and the header is missing.
+func postIfMatch(c *gin.Context) ([]string, bool) {
+	ifMatch := tools.ParseETagList(c.GetHeader("If-Match"))
+
+	if ifMatch == nil && config.GetPostRequireIfMatch() {
+		c.AbortWithStatusJSON(http.StatusPreconditionRequired, &model.PostResponse{
+			Success: false,
+			Message: "If-Match header is required",
+			Data:    nil,
+		})
+		return nil, false
+	}
+
+	return ifMatch, true
+}
+
+// postAbortError aborts a post write. A failed precondition also sends the
+// current post and its ETag, so the client can merge and retry.
+func postAbortError(c *gin.Context, err error) {
+	code, message := tools.APIErrorResponse(err)
+
+	var current *model.Post
+	if customErr, ok := err.(*tools.CustomError); ok && code == http.StatusPreconditionFailed {
+		current, _ = customErr.Data.(*model.Post)
+	}
+
+	if current != nil {
+		current.ContentHTML = ""
+		c.Header("ETag", tools.PostETag(current.ID, current.Version))
+	}
+
+	c.AbortWithStatusJSON(code, &model.PostResponse{
+		Success: false,
+		Message: message,
+		Data:    current,
+	})
+}
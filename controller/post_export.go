@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"log"
+	"myapp/model"
+	"myapp/service"
+	"myapp/tools"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var postExportContentTypes = map[string]string{
+	model.PostExportJSONL: "application/x-ndjson",
+	model.PostExportCSV:   "text/csv",
+}
+
+// PostExport godoc
+// @Summary Export posts
+// @Description Stream all your posts as JSON lines or CSV, every column included. CSV joins tags and categories with "|".
+// @Tags Post
+// @Produce plain
+// @Param format query string false "Export format (default jsonl)" Enums(jsonl, csv)
+// @Param include_deleted query bool false "Include posts in the trash"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {string} string "One post per line"
+// @Failure 400 {object} model.GlobalResponse
+// @Failure 500 {object} model.GlobalResponse
+// @Router /posts/export [get]
+func PostExport(c *gin.Context) {
+	var (
+		filter model.PostExportFilter
+	)
+
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.GlobalResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if filter.Format == "" {
+		filter.Format = model.PostExportJSONL
+	}
+
+	contentType, ok := postExportContentTypes[filter.Format]
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.GlobalResponse{
+			Success: false,
+			Message: "Invalid format, expected jsonl or csv",
+		})
+		return
+	}
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				// Rows may already be on the wire, all that is left is to cut the
+				// response short.
+				log.Println("post export:", err)
+				c.Abort()
+				return
+			}
+		}
+	}()
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="posts.`+filter.Format+`"`)
+	c.Status(http.StatusOK)
+
+	s.PostExportOwn(c.Request.Context(), filter, c.Writer)
+}
+
+// PostImport godoc
+// @Summary Import posts
+// @Description Create posts from a JSON lines or CSV file in the export format, with you as the author. Rows are validated like POST /post and stored in batches, rows that fail are reported by line and skipped.
+// @Tags Post
+// @Accept plain
+// @Produce json
+// @Param format query string false "Import format, defaults to the Content-Type (application/x-ndjson or text/csv)" Enums(jsonl, csv)
+// @Param body body string true "Export file"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.PostImportResponse
+// @Failure 400 {object} model.PostImportResponse
+// @Failure 500 {object} model.PostImportResponse
+// @Router /posts/import [post]
+func PostImport(c *gin.Context) {
+	format := c.Query("format")
+
+	if format == "" {
+		for name, contentType := range postExportContentTypes {
+			if strings.EqualFold(c.ContentType(), contentType) {
+				format = name
+			}
+		}
+	}
+
+	result, err := service.PostImportOwn(c.Request.Context(), format, c.Request.Body)
+	if err != nil {
+		code, message := tools.APIErrorResponse(err)
+		c.AbortWithStatusJSON(code, &model.PostImportResponse{
+			Success: false,
+			Message: message,
+			Data:    result,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, &model.PostImportResponse{
+		Success: true,
+		Message: "Success",
+		Data:    result,
+	})
+}
@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"myapp/model"
+	"myapp/service"
+	"myapp/tools"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PostRevisionGetAll godoc
+// @Summary Get post revisions
+// @Description Get the revision history of a post, newest first
+// @Tags Post
+// @Accept json
+// @Produce json
+// @Param id query int true "Post id"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.PostRevisionMultipleResponse
+// @Failure 400 {object} model.PostRevisionMultipleResponse
+// @Failure 403 {object} model.PostRevisionMultipleResponse
+// @Failure 404 {object} model.PostRevisionMultipleResponse
+// @Failure 500 {object} model.PostRevisionMultipleResponse
+// @Router /post/revisions [get]
+func PostRevisionGetAll(c *gin.Context) {
+	postIDStr := c.Query("id")
+
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.PostRevisionMultipleResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.PostRevisionMultipleResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	revisions, _ := s.PostRevisionGetAllByPostID(c.Request.Context(), postID)
+
+	c.JSON(http.StatusOK, &model.PostRevisionMultipleResponse{
+		Success: true,
+		Message: "Success",
+		Data:    revisions,
+	})
+}
+
+// PostRevisionDiff godoc
+// @Summary Diff post revisions
+// @Description Get a unified diff between two revisions of a post, use 0 for the current post
+// @Tags Post
+// @Accept json
+// @Produce json
+// @Param id query int true "Post id"
+// @Param from query int true "Revision id"
+// @Param to query int false "Revision id (default 0, the current post)"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.PostRevisionDiffResponse
+// @Failure 400 {object} model.PostRevisionDiffResponse
+// @Failure 403 {object} model.PostRevisionDiffResponse
+// @Failure 404 {object} model.PostRevisionDiffResponse
+// @Failure 500 {object} model.PostRevisionDiffResponse
+// @Router /post/revisions/diff [get]
+func PostRevisionDiff(c *gin.Context) {
+	postID, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.PostRevisionDiffResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.PostRevisionDiffResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	to, err := strconv.Atoi(c.DefaultQuery("to", "0"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.PostRevisionDiffResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.PostRevisionDiffResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	diff, _ := s.PostRevisionDiff(c.Request.Context(), postID, from, to)
+
+	c.JSON(http.StatusOK, &model.PostRevisionDiffResponse{
+		Success: true,
+		Message: "Success",
+		Data:    diff,
+	})
+}
+
+// PostRevisionRestore godoc
+// @Summary Restore post revision
+// @Description Restore a post to a revision, the replaced state is kept as a new revision
+// @Tags Post
+// @Accept json
+// @Produce json
+// @Param body body model.PostRevisionRestore true "Revision to restore"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.PostResponse
+// @Failure 400 {object} model.PostResponse
+// @Failure 403 {object} model.PostResponse
+// @Failure 404 {object} model.PostResponse
+// @Failure 500 {object} model.PostResponse
+// @Router /post/revisions/restore [post]
+func PostRevisionRestore(c *gin.Context) {
+	var (
+		input model.PostRevisionRestore
+	)
+
+	err := c.ShouldBind(&input)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.PostResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.PostResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	post, _ := s.PostRevisionRestore(c.Request.Context(), input)
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.PostResponse{
+		Success: true,
+		Message: "Success",
+		Data:    post,
+	})
+}
@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"myapp/model"
+	"myapp/service"
+	"myapp/tools"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PostGetTrash godoc
+// @Summary List deleted posts
+// @Description List your deleted posts, most recently deleted first. Each post carries the time it will be purged.
+// @Tags Post
+// @Accept json
+// @Produce json
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.PostMultipleResponse
+// @Failure 400 {object} model.PostMultipleResponse
+// @Failure 500 {object} model.PostMultipleResponse
+// @Router /user/trash [get]
+func PostGetTrash(c *gin.Context) {
+	var (
+		filter model.PostTrashFilter
+	)
+
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.PostMultipleResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.PostMultipleResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	page, _ := s.PostGetTrash(c.Request.Context(), filter)
+
+	for _, post := range page.Posts {
+		post.ContentHTML = ""
+	}
+
+	c.JSON(http.StatusOK, &model.PostMultipleResponse{
+		Success:    true,
+		Message:    "Success",
+		Data:       page.Posts,
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+	})
+}
+
+// PostRestore godoc
+// @Summary Restore deleted post
+// @Description Move one of your deleted posts out of the trash
+// @Tags Post
+// @Accept json
+// @Produce json
+// @Param id query int true "Post id"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.PostResponse
+// @Failure 400 {object} model.PostResponse
+// @Failure 403 {object} model.PostResponse
+// @Failure 404 {object} model.PostResponse
+// @Failure 500 {object} model.PostResponse
+// @Router /post/restore [post]
+func PostRestore(c *gin.Context) {
+	postIDStr := c.Query("id")
+
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.PostResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.PostResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	post, _ := s.PostRestore(c.Request.Context(), postID)
+	s.Commit()
+
+	post.ContentHTML = ""
+
+	c.JSON(http.StatusOK, &model.PostResponse{
+		Success: true,
+		Message: "Success",
+		Data:    post,
+	})
+}
+
+// PostPurge godoc
+// @Summary Purge post
+// @Description Permanently delete a post and everything attached to it. Operators only.
+// @Tags Post
+// @Accept json
+// @Produce json
+// @Param id query int true "Post id"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.GlobalResponse
+// @Failure 400 {object} model.GlobalResponse
+// @Failure 403 {object} model.GlobalResponse
+// @Failure 404 {object} model.GlobalResponse
+// @Failure 500 {object} model.GlobalResponse
+// @Router /post/purge [delete]
+func PostPurge(c *gin.Context) {
+	postIDStr := c.Query("id")
+
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.GlobalResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.GlobalResponse{
+					Success: false,
+					Message: message,
+				})
+				return
+			}
+		}
+	}()
+
+	resp, _ := s.PostPurgeByID(c.Request.Context(), postID)
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.GlobalResponse{
+		Success: true,
+		Message: resp,
+	})
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"myapp/model"
+	"myapp/service"
+	"myapp/tools"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PostReactionPut godoc
+// @Summary React to post
+// @Description Add a reaction to a post, repeating the request has no further effect
+// @Tags Reaction
+// @Accept json
+// @Produce json
+// @Param id query int true "Post id"
+// @Param type query string true "Reaction type" Enums(like, love, laugh, wow, sad, angry)
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.ReactionSummaryResponse
+// @Failure 400 {object} model.ReactionSummaryResponse
+// @Failure 404 {object} model.ReactionSummaryResponse
+// @Failure 500 {object} model.ReactionSummaryResponse
+// @Router /post/reaction [put]
+func PostReactionPut(c *gin.Context) {
+	postReactionWrite(c, func(s *service.Service, postID int, reactionType string) []*model.ReactionSummary {
+		summary, _ := s.PostReactionPut(c.Request.Context(), postID, reactionType)
+		return summary
+	})
+}
+
+// PostReactionDelete godoc
+// @Summary Remove post reaction
+// @Description Remove a reaction from a post, repeating the request has no further effect
+// @Tags Reaction
+// @Accept json
+// @Produce json
+// @Param id query int true "Post id"
+// @Param type query string true "Reaction type" Enums(like, love, laugh, wow, sad, angry)
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.ReactionSummaryResponse
+// @Failure 400 {object} model.ReactionSummaryResponse
+// @Failure 404 {object} model.ReactionSummaryResponse
+// @Failure 500 {object} model.ReactionSummaryResponse
+// @Router /post/reaction [delete]
+func PostReactionDelete(c *gin.Context) {
+	postReactionWrite(c, func(s *service.Service, postID int, reactionType string) []*model.ReactionSummary {
+		summary, _ := s.PostReactionDelete(c.Request.Context(), postID, reactionType)
+		return summary
+	})
+}
+
+func postReactionWrite(c *gin.Context, write func(s *service.Service, postID int, reactionType string) []*model.ReactionSummary) {
+	postIDStr := c.Query("id")
+
+	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.ReactionSummaryResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.ReactionSummaryResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	summary := write(s, postID, c.Query("type"))
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.ReactionSummaryResponse{
+		Success: true,
+		Message: "Success",
+		Data:    summary,
+	})
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"myapp/model"
+	"myapp/service"
+	"myapp/tools"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionGetAll godoc
+// @Summary List sessions
+// @Description List the devices you are signed in on, most recently used first. The session of the request is marked current
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.SessionMultipleResponse
+// @Failure 500 {object} model.SessionMultipleResponse
+// @Router /user/sessions [get]
+func SessionGetAll(c *gin.Context) {
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.SessionMultipleResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	sessions, _ := s.SessionGetAll(c.Request.Context())
+
+	c.JSON(http.StatusOK, &model.SessionMultipleResponse{
+		Success: true,
+		Message: "Success",
+		Data:    sessions,
+	})
+}
+
+// SessionDelete godoc
+// @Summary Revoke session
+// @Description Sign out one of your sessions, its refresh and access tokens stop working at once
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param id path int true "Session id"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.GlobalResponse
+// @Failure 400 {object} model.GlobalResponse
+// @Failure 404 {object} model.GlobalResponse
+// @Failure 500 {object} model.GlobalResponse
+// @Router /user/sessions/{id} [delete]
+func SessionDelete(c *gin.Context) {
+	sessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.GlobalResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.GlobalResponse{
+					Success: false,
+					Message: message,
+				})
+				return
+			}
+		}
+	}()
+
+	resp, _ := s.SessionDeleteByID(c.Request.Context(), sessionID)
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.GlobalResponse{
+		Success: true,
+		Message: resp,
+	})
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"myapp/model"
+	"myapp/service"
+	"myapp/tools"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TagGetAll godoc
+// @Summary Get all tags
+// @Description Get tags with the number of published posts using them, most used first
+// @Tags Tag
+// @Accept json
+// @Produce json
+// @Param limit query int false "Page size (default 100, max 500)"
+// @Param offset query int false "Number of tags to skip"
+// @Success 200 {object} model.TagMultipleResponse
+// @Failure 400 {object} model.TagMultipleResponse
+// @Failure 500 {object} model.TagMultipleResponse
+// @Router /tags [get]
+func TagGetAll(c *gin.Context) {
+	var (
+		filter model.TagFilter
+	)
+
+	err := c.ShouldBindQuery(&filter)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.TagMultipleResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.TagMultipleResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	page, _ := s.TagGetAll(c.Request.Context(), filter)
+
+	c.JSON(http.StatusOK, &model.TagMultipleResponse{
+		Success: true,
+		Message: "Success",
+		Data:    page.Tags,
+		HasMore: page.HasMore,
+	})
+}
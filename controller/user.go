@@ -1,10 +1,13 @@
 package controller
 
 import (
+	"io"
+	"myapp/config"
 	"myapp/model"
 	"myapp/service"
 	"myapp/tools"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -142,3 +145,216 @@ func UserGetMe(c *gin.Context) {
 		Data:    tools.UserToUserData(*user),
 	})
 }
+
+// UserAvatarUpload godoc
+// @Summary Upload avatar
+// @Description Upload a JPEG, PNG, GIF or WebP avatar as multipart/form-data. It is resized in the background, avatar_status is "processing" until then
+// @Tags User
+// @Accept mpfd
+// @Produce json
+// @Param file formData file true "Avatar image"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 202 {object} model.UserResponse
+// @Failure 400 {object} model.UserResponse
+// @Failure 413 {object} model.UserResponse
+// @Failure 415 {object} model.UserResponse
+// @Failure 500 {object} model.UserResponse
+// @Router /user/avatar [post]
+func UserAvatarUpload(c *gin.Context) {
+	maxSize := config.GetAvatarMaxSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.UserResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.UserResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.UserResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	user, _ := s.UserAvatarUpload(c.Request.Context(), data)
+	s.Commit()
+
+	c.JSON(http.StatusAccepted, &model.UserResponse{
+		Success: true,
+		Message: "Success",
+		Data:    tools.UserToUserData(*user),
+	})
+}
+
+// UserAvatarDelete godoc
+// @Summary Delete avatar
+// @Description Remove your avatar, the generated identicon is served instead
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.UserResponse
+// @Failure 500 {object} model.UserResponse
+// @Router /user/avatar [delete]
+func UserAvatarDelete(c *gin.Context) {
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.UserResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	user, _ := s.UserAvatarDelete(c.Request.Context())
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.UserResponse{
+		Success: true,
+		Message: "Success",
+		Data:    tools.UserToUserData(*user),
+	})
+}
+
+// UserSetHandle godoc
+// @Summary Set handle
+// @Description Set the handle other users @mention you by, 3 to 30 letters, digits or underscores. Handles are case insensitive and stored lowercase
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param body body model.UserHandleInput true "Handle data"
+// @Param Authorization header string true "Bearer JWT token"
+// @Success 200 {object} model.UserResponse
+// @Failure 400 {object} model.UserResponse
+// @Failure 409 {object} model.UserResponse
+// @Failure 500 {object} model.UserResponse
+// @Router /user/handle [put]
+func UserSetHandle(c *gin.Context) {
+	var (
+		input model.UserHandleInput
+	)
+
+	err := c.ShouldBind(&input)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.UserResponse{
+			Success: false,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+
+	s := service.GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.UserResponse{
+					Success: false,
+					Message: message,
+					Data:    nil,
+				})
+				return
+			}
+		}
+	}()
+
+	user, _ := s.UserSetHandle(c.Request.Context(), input)
+	s.Commit()
+
+	c.JSON(http.StatusOK, &model.UserResponse{
+		Success: true,
+		Message: "Success",
+		Data:    tools.UserToUserData(*user),
+	})
+}
+
+// UserAvatarGet godoc
+// @Summary Get user avatar
+// @Description Get a user avatar as PNG, or an identicon for users without one. URLs with a v parameter are cached for good
+// @Tags User
+// @Produce png
+// @Param id path int true "User id"
+// @Param size query int false "Wanted size in pixels, rounded up to a generated size"
+// @Param v query string false "Avatar version from avatar_url"
+// @Success 200 {file} file
+// @Success 304 "Not modified"
+// @Failure 404 {object} model.GlobalResponse
+// @Failure 500 {object} model.GlobalResponse
+// @Router /users/{id}/avatar [get]
+func UserAvatarGet(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, &model.GlobalResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	size, _ := strconv.Atoi(c.Query("size"))
+
+	s := service.GetService()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.ErrorCheck(r)
+			if err != nil {
+				code, message := tools.APIErrorResponse(err)
+				c.AbortWithStatusJSON(code, &model.GlobalResponse{
+					Success: false,
+					Message: message,
+				})
+				return
+			}
+		}
+	}()
+
+	avatar, _ := s.UserAvatarGet(c.Request.Context(), userID, size)
+
+	if c.Query("v") != "" {
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		c.Header("Cache-Control", "public, max-age=300")
+	}
+	c.Header("ETag", avatar.ETag)
+
+	if c.GetHeader("If-None-Match") == avatar.ETag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, avatar.ContentType, avatar.Data)
+}
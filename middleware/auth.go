@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"myapp/config"
 	"myapp/model"
 	"myapp/tools"
 	"net/http"
@@ -18,6 +19,9 @@ type contextKey struct {
 
 type User struct {
 	ID int `json:"id"`
+
+	// TokenHash identifies the access token the request was made with.
+	TokenHash string `json:"-"`
 }
 
 func AuthMiddleware() gin.HandlerFunc {
@@ -73,7 +77,8 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		ctx := context.WithValue(c.Request.Context(), CtxKey, &User{
-			ID: claims.ID,
+			ID:        claims.ID,
+			TokenHash: tools.HashSHA256(authTokens[1]),
 		})
 
 		c.Request = c.Request.WithContext(ctx)
@@ -100,3 +105,29 @@ func AuthContext(ctx context.Context) *User {
 	raw, _ := ctx.Value(CtxKey).(*User)
 	return raw
 }
+
+// IsOperator only lets through users listed in OPERATOR_USER_IDS.
+func IsOperator() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := AuthContext(c.Request.Context())
+		if user == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, &model.GlobalResponse{
+				Success: false,
+				Message: "Unauthorized",
+			})
+			return
+		}
+
+		for _, id := range config.GetOperatorUserIDs() {
+			if id == user.ID {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, &model.GlobalResponse{
+			Success: false,
+			Message: "Forbidden",
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ClientCtxKey = &contextKey{"client"}
+
+// Client is who sent the request, as far as the server can tell.
+type Client struct {
+	UserAgent string `json:"user_agent"`
+	IPAddress string `json:"ip_address"`
+}
+
+func ClientMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.WithValue(c.Request.Context(), ClientCtxKey, &Client{
+			UserAgent: c.Request.UserAgent(),
+			IPAddress: c.ClientIP(),
+		})
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// ClientContext returns an empty Client outside of a request, e.g. for CLI
+// commands.
+func ClientContext(ctx context.Context) *Client {
+	raw, ok := ctx.Value(ClientCtxKey).(*Client)
+	if !ok {
+		return &Client{}
+	}
+
+	return raw
+}
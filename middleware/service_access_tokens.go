@@ -5,7 +5,6 @@ import (
 	"myapp/config"
 	"myapp/model"
 	"myapp/tools"
-	"time"
 )
 
 func AccessTokenCheckExistByRawToken(ctx context.Context, token string) (bool, error) {
@@ -17,7 +16,7 @@ func AccessTokenCheckExistByRawToken(ctx context.Context, token string) (bool, e
 
 	hashedToken := tools.HashSHA256(token)
 
-	if err := db.Model(&accessToken).Where("token_hash = ? AND expired_at > ? AND revoked_at IS NULL", hashedToken, time.Now().UTC()).Count(&count).Error; err != nil {
+	if err := db.Model(&accessToken).Scopes(tools.IsAccessTokenActive).Where("token_hash = ?", hashedToken).Count(&count).Error; err != nil {
 		return false, err
 	}
 
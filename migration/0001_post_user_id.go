@@ -0,0 +1,77 @@
+package migration
+
+import (
+	"fmt"
+	"myapp/config"
+
+	"gorm.io/gorm"
+)
+
+// Posts created before authorship existed are assigned to the configured
+// system user (SYSTEM_USER_ID). DDL is not transactional in MySQL, so the
+// backfill is validated before the table is touched and every schema change
+// is skipped when an earlier failed run already made it.
+func postUserID(db *gorm.DB) error {
+	var (
+		hasColumn int64
+		nullable  []string
+		hasIndex  int64
+		count     int64
+	)
+
+	if err := db.Table("information_schema.columns").
+		Where("table_schema = DATABASE() AND table_name = ? AND column_name = ?", "post", "user_id").
+		Count(&hasColumn).Error; err != nil {
+		return err
+	}
+
+	query := db.Table("post")
+	if hasColumn > 0 {
+		query = query.Where("user_id IS NULL")
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+
+	systemUserID := config.GetSystemUserID()
+	if count > 0 && systemUserID == 0 {
+		return fmt.Errorf("SYSTEM_USER_ID is required to backfill %d existing posts", count)
+	}
+
+	if hasColumn == 0 {
+		if err := db.Exec("ALTER TABLE post ADD COLUMN user_id INT NULL AFTER id").Error; err != nil {
+			return err
+		}
+	}
+
+	if count > 0 {
+		if err := db.Exec("UPDATE post SET user_id = ? WHERE user_id IS NULL", systemUserID).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := db.Table("information_schema.columns").
+		Where("table_schema = DATABASE() AND table_name = ? AND column_name = ?", "post", "user_id").
+		Pluck("is_nullable", &nullable).Error; err != nil {
+		return err
+	}
+
+	if len(nullable) == 0 || nullable[0] == "YES" {
+		if err := db.Exec("ALTER TABLE post MODIFY COLUMN user_id INT NOT NULL").Error; err != nil {
+			return err
+		}
+	}
+
+	if err := db.Table("information_schema.statistics").
+		Where("table_schema = DATABASE() AND table_name = ? AND index_name = ?", "post", "idx_post_user_id").
+		Count(&hasIndex).Error; err != nil {
+		return err
+	}
+
+	if hasIndex > 0 {
+		return nil
+	}
+
+	return db.Exec("CREATE INDEX idx_post_user_id ON post (user_id)").Error
+}
@@ -0,0 +1,12 @@
+package migration
+
+import "gorm.io/gorm"
+
+// Composite indexes backing keyset pagination on GET /posts.
+func postPaginationIndex(db *gorm.DB) error {
+	if err := db.Exec("CREATE INDEX idx_post_created_at_id ON post (created_at, id)").Error; err != nil {
+		return err
+	}
+
+	return db.Exec("CREATE INDEX idx_post_title_id ON post (title(191), id)").Error
+}
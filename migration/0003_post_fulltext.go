@@ -0,0 +1,8 @@
+package migration
+
+import "gorm.io/gorm"
+
+// FULLTEXT index used by the mysql search backend.
+func postFulltext(db *gorm.DB) error {
+	return db.Exec("ALTER TABLE post ADD FULLTEXT INDEX ft_post_title_content (title, content)").Error
+}
@@ -0,0 +1,15 @@
+package migration
+
+import "gorm.io/gorm"
+
+func postRevision(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE post_revision (
+		id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		post_id INT NOT NULL,
+		editor_id INT NOT NULL,
+		title VARCHAR(255) NOT NULL,
+		content LONGTEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		INDEX idx_post_revision_post_id (post_id, id)
+	)`).Error
+}
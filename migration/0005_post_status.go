@@ -0,0 +1,17 @@
+package migration
+
+import "gorm.io/gorm"
+
+// Existing posts were public as soon as they were created, so they become
+// published as of their creation time.
+func postStatus(db *gorm.DB) error {
+	if err := db.Exec("ALTER TABLE post ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'published' AFTER content, ADD COLUMN publish_at DATETIME NULL AFTER status").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("UPDATE post SET publish_at = created_at WHERE publish_at IS NULL").Error; err != nil {
+		return err
+	}
+
+	return db.Exec("CREATE INDEX idx_post_status_publish_at ON post (status, publish_at)").Error
+}
@@ -0,0 +1,42 @@
+package migration
+
+import "gorm.io/gorm"
+
+func tagCategory(db *gorm.DB) error {
+	statements := []string{
+		`CREATE TABLE tag (
+			id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(64) NOT NULL,
+			slug VARCHAR(64) NOT NULL,
+			created_at DATETIME NOT NULL,
+			UNIQUE INDEX uq_tag_slug (slug)
+		)`,
+		`CREATE TABLE category (
+			id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(64) NOT NULL,
+			slug VARCHAR(64) NOT NULL,
+			created_at DATETIME NOT NULL,
+			UNIQUE INDEX uq_category_slug (slug)
+		)`,
+		`CREATE TABLE post_tag (
+			post_id INT NOT NULL,
+			tag_id INT NOT NULL,
+			PRIMARY KEY (post_id, tag_id),
+			INDEX idx_post_tag_tag_id (tag_id)
+		)`,
+		`CREATE TABLE post_category (
+			post_id INT NOT NULL,
+			category_id INT NOT NULL,
+			PRIMARY KEY (post_id, category_id),
+			INDEX idx_post_category_category_id (category_id)
+		)`,
+	}
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,18 @@
+package migration
+
+import "gorm.io/gorm"
+
+func comment(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE comment (
+		id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		post_id INT NOT NULL,
+		user_id INT NOT NULL,
+		parent_id INT NULL,
+		body TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NULL,
+		deleted_at DATETIME NULL,
+		INDEX idx_comment_post_id (post_id, deleted_at, id),
+		INDEX idx_comment_parent_id (parent_id)
+	)`).Error
+}
@@ -0,0 +1,23 @@
+package migration
+
+import "gorm.io/gorm"
+
+func postReaction(db *gorm.DB) error {
+	if err := db.Exec(`CREATE TABLE post_reaction (
+		post_id INT NOT NULL,
+		user_id INT NOT NULL,
+		type VARCHAR(16) NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (post_id, user_id, type),
+		INDEX idx_post_reaction_user_id (user_id, post_id)
+	)`).Error; err != nil {
+		return err
+	}
+
+	return db.Exec(`CREATE TABLE post_reaction_count (
+		post_id INT NOT NULL,
+		type VARCHAR(16) NOT NULL,
+		count INT NOT NULL DEFAULT 0,
+		PRIMARY KEY (post_id, type)
+	)`).Error
+}
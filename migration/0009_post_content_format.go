@@ -0,0 +1,36 @@
+package migration
+
+import (
+	"myapp/tools"
+
+	"gorm.io/gorm"
+)
+
+type postContent struct {
+	ID      int
+	Content string
+}
+
+// Existing posts are plain text, their cached HTML is rendered here so reads
+// never have to.
+func postContentFormat(db *gorm.DB) error {
+	if err := db.Exec("ALTER TABLE post ADD COLUMN content_format VARCHAR(16) NOT NULL DEFAULT 'plain' AFTER content, ADD COLUMN content_html LONGTEXT NULL AFTER content_format").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("ALTER TABLE post_revision ADD COLUMN content_format VARCHAR(16) NOT NULL DEFAULT 'plain' AFTER content").Error; err != nil {
+		return err
+	}
+
+	var rows []postContent
+
+	return db.Table("post").Select("id, content").Where("content_html IS NULL").FindInBatches(&rows, 500, func(tx *gorm.DB, batch int) error {
+		for _, row := range rows {
+			if err := db.Exec("UPDATE post SET content_html = ? WHERE id = ?", tools.PlainToHTML(row.Content), row.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}).Error
+}
@@ -0,0 +1,58 @@
+package migration
+
+import (
+	"fmt"
+	"myapp/tools"
+
+	"gorm.io/gorm"
+)
+
+type postTitle struct {
+	ID    int
+	Title string
+}
+
+func postSlug(db *gorm.DB) error {
+	if err := db.Exec("ALTER TABLE post ADD COLUMN slug VARCHAR(100) NULL AFTER user_id").Error; err != nil {
+		return err
+	}
+
+	var rows []postTitle
+	taken := map[string]bool{}
+
+	if err := db.Table("post").Select("id, title").Order("id").FindInBatches(&rows, 500, func(tx *gorm.DB, batch int) error {
+		for _, row := range rows {
+			base := tools.SlugifyASCII(row.Title, 80)
+			if base == "" {
+				base = "post"
+			}
+
+			slug := base
+			for i := 2; taken[slug]; i++ {
+				slug = fmt.Sprintf("%s-%d", base, i)
+			}
+			taken[slug] = true
+
+			if err := db.Exec("UPDATE post SET slug = ? WHERE id = ?", slug, row.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}).Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("ALTER TABLE post MODIFY COLUMN slug VARCHAR(100) NOT NULL, ADD UNIQUE INDEX uq_post_slug (slug)").Error; err != nil {
+		return err
+	}
+
+	return db.Exec(`CREATE TABLE post_slug_redirect (
+		id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		post_id INT NOT NULL,
+		slug VARCHAR(100) NOT NULL,
+		created_at DATETIME NOT NULL,
+		UNIQUE INDEX uq_post_slug_redirect_slug (slug),
+		INDEX idx_post_slug_redirect_post_id (post_id)
+	)`).Error
+}
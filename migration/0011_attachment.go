@@ -0,0 +1,19 @@
+package migration
+
+import "gorm.io/gorm"
+
+func attachment(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE attachment (
+		id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		post_id INT NOT NULL,
+		user_id INT NOT NULL,
+		file_name VARCHAR(255) NOT NULL,
+		content_type VARCHAR(100) NOT NULL,
+		size BIGINT NOT NULL,
+		hash CHAR(64) NOT NULL,
+		created_at DATETIME NOT NULL,
+		deleted_at DATETIME NULL,
+		INDEX idx_attachment_post_id (post_id),
+		INDEX idx_attachment_hash (hash)
+	)`).Error
+}
@@ -0,0 +1,7 @@
+package migration
+
+import "gorm.io/gorm"
+
+func userAvatar(db *gorm.DB) error {
+	return db.Exec("ALTER TABLE user ADD COLUMN avatar_status VARCHAR(16) NOT NULL DEFAULT 'none' AFTER password, ADD COLUMN avatar_hash CHAR(64) NULL AFTER avatar_status, ADD COLUMN avatar_pending_hash CHAR(64) NULL AFTER avatar_hash").Error
+}
@@ -0,0 +1,12 @@
+package migration
+
+import "gorm.io/gorm"
+
+// Indexes backing the trash listing and the retention purge.
+func postTrash(db *gorm.DB) error {
+	if err := db.Exec("CREATE INDEX idx_post_user_id_deleted_at ON post (user_id, deleted_at, id)").Error; err != nil {
+		return err
+	}
+
+	return db.Exec("CREATE INDEX idx_post_deleted_at ON post (deleted_at)").Error
+}
@@ -0,0 +1,8 @@
+package migration
+
+import "gorm.io/gorm"
+
+// Version is bumped on every write to a post and backs its ETag.
+func postVersion(db *gorm.DB) error {
+	return db.Exec("ALTER TABLE post ADD COLUMN version INT NOT NULL DEFAULT 1 AFTER status").Error
+}
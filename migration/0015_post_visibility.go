@@ -0,0 +1,8 @@
+package migration
+
+import "gorm.io/gorm"
+
+// Existing posts stay public.
+func postVisibility(db *gorm.DB) error {
+	return db.Exec("ALTER TABLE post ADD COLUMN visibility VARCHAR(16) NOT NULL DEFAULT 'public' AFTER status").Error
+}
@@ -0,0 +1,33 @@
+package migration
+
+import "gorm.io/gorm"
+
+// timeline_entry is only filled with TIMELINE_STRATEGY=write, it holds one row
+// per post per follower of its author.
+func followTimeline(db *gorm.DB) error {
+	if err := db.Exec(`CREATE TABLE follow (
+		follower_id INT NOT NULL,
+		followee_id INT NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (follower_id, followee_id),
+		INDEX idx_follow_followee_id (followee_id, created_at),
+		INDEX idx_follow_follower_id_created_at (follower_id, created_at)
+	)`).Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE INDEX idx_post_user_id_publish_at ON post (user_id, publish_at, id)").Error; err != nil {
+		return err
+	}
+
+	return db.Exec(`CREATE TABLE timeline_entry (
+		user_id INT NOT NULL,
+		post_id INT NOT NULL,
+		author_id INT NOT NULL,
+		published_at DATETIME NOT NULL,
+		PRIMARY KEY (user_id, post_id),
+		INDEX idx_timeline_entry_user_id_published_at (user_id, published_at, post_id),
+		INDEX idx_timeline_entry_post_id (post_id),
+		INDEX idx_timeline_entry_author_id (user_id, author_id)
+	)`).Error
+}
@@ -0,0 +1,28 @@
+package migration
+
+import "gorm.io/gorm"
+
+// A post is bookmarked at most once per user, folder_id NULL means it is not
+// in any reading list.
+func bookmark(db *gorm.DB) error {
+	if err := db.Exec(`CREATE TABLE bookmark_folder (
+		id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		user_id INT NOT NULL,
+		name VARCHAR(100) NOT NULL,
+		created_at DATETIME NOT NULL,
+		UNIQUE INDEX uq_bookmark_folder_user_id_name (user_id, name)
+	)`).Error; err != nil {
+		return err
+	}
+
+	return db.Exec(`CREATE TABLE bookmark (
+		user_id INT NOT NULL,
+		post_id INT NOT NULL,
+		folder_id INT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (user_id, post_id),
+		INDEX idx_bookmark_user_id_created_at (user_id, created_at, post_id),
+		INDEX idx_bookmark_folder_id_created_at (folder_id, created_at, post_id),
+		INDEX idx_bookmark_post_id (post_id)
+	)`).Error
+}
@@ -0,0 +1,26 @@
+package migration
+
+import "gorm.io/gorm"
+
+// Refresh tokens remember the client they were last used from, so a refresh
+// from another client can be told to the user.
+func notification(db *gorm.DB) error {
+	if err := db.Exec(`CREATE TABLE notification (
+		id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		user_id INT NOT NULL,
+		type VARCHAR(32) NOT NULL,
+		message VARCHAR(500) NOT NULL,
+		actor_id INT NULL,
+		post_id INT NULL,
+		read_at DATETIME NULL,
+		created_at DATETIME NOT NULL,
+		INDEX idx_notification_user_id_id (user_id, id),
+		INDEX idx_notification_user_id_read_at (user_id, read_at)
+	)`).Error; err != nil {
+		return err
+	}
+
+	return db.Exec(`ALTER TABLE refresh_tokens
+		ADD COLUMN user_agent VARCHAR(255) NOT NULL DEFAULT '',
+		ADD COLUMN ip_address VARCHAR(45) NOT NULL DEFAULT ''`).Error
+}
@@ -0,0 +1,19 @@
+package migration
+
+import "gorm.io/gorm"
+
+// Handles are optional for users created before they existed, MySQL allows
+// any number of NULLs in a unique index.
+func userHandleMention(db *gorm.DB) error {
+	if err := db.Exec("ALTER TABLE user ADD COLUMN handle VARCHAR(30) NULL AFTER name, ADD UNIQUE INDEX uq_user_handle (handle)").Error; err != nil {
+		return err
+	}
+
+	return db.Exec(`CREATE TABLE post_mention (
+		post_id INT NOT NULL,
+		user_id INT NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (post_id, user_id),
+		INDEX idx_post_mention_user_id_created_at (user_id, created_at, post_id)
+	)`).Error
+}
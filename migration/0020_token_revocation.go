@@ -0,0 +1,17 @@
+package migration
+
+import "gorm.io/gorm"
+
+// Logout revokes tokens by the refresh token they were issued from, or by
+// user for logout everywhere.
+func tokenRevocation(db *gorm.DB) error {
+	if err := db.Exec("CREATE INDEX idx_access_tokens_refresh_token_id ON access_tokens (refresh_token_id)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE INDEX idx_access_tokens_user_id ON access_tokens (user_id)").Error; err != nil {
+		return err
+	}
+
+	return db.Exec("CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens (user_id)").Error
+}
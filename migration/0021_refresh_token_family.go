@@ -0,0 +1,33 @@
+package migration
+
+import "gorm.io/gorm"
+
+// A refresh token is replaced on every use, the tokens descending from one
+// login share the id of the first as family_id. Existing tokens each start
+// their own family.
+func refreshTokenFamily(db *gorm.DB) error {
+	if err := db.Exec(`ALTER TABLE refresh_tokens
+		ADD COLUMN family_id INT NULL AFTER user_id,
+		ADD COLUMN replaced_by_id INT NULL AFTER family_id`).Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("UPDATE refresh_tokens SET family_id = id").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id)").Error; err != nil {
+		return err
+	}
+
+	return db.Exec(`CREATE TABLE security_event (
+		id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		user_id INT NOT NULL,
+		type VARCHAR(32) NOT NULL,
+		refresh_token_family_id INT NULL,
+		user_agent VARCHAR(255) NOT NULL DEFAULT '',
+		ip_address VARCHAR(45) NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		INDEX idx_security_event_user_id_created_at (user_id, created_at)
+	)`).Error
+}
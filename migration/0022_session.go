@@ -0,0 +1,15 @@
+package migration
+
+import "gorm.io/gorm"
+
+// The live refresh token of a family is a session, it carries what the
+// session list shows.
+func session(db *gorm.DB) error {
+	if err := db.Exec(`ALTER TABLE refresh_tokens
+		ADD COLUMN device_label VARCHAR(100) NOT NULL DEFAULT '' AFTER ip_address,
+		ADD COLUMN last_used_at DATETIME NULL AFTER device_label`).Error; err != nil {
+		return err
+	}
+
+	return db.Exec("UPDATE refresh_tokens SET last_used_at = created_at").Error
+}
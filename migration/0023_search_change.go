@@ -0,0 +1,14 @@
+package migration
+
+import "gorm.io/gorm"
+
+// Every post written is logged, so server instances keeping their own search
+// index can pick up what the others indexed.
+func searchChange(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE search_change (
+		id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		post_id INT NOT NULL,
+		created_at DATETIME NOT NULL,
+		INDEX idx_search_change_created_at (created_at)
+	)`).Error
+}
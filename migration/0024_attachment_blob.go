@@ -0,0 +1,22 @@
+package migration
+
+import "gorm.io/gorm"
+
+// Stored attachment files get a row of their own, locked by every upload and
+// delete of the file, so a file is only removed by the sweeper once nothing
+// refers to it. Files of existing attachments are registered, those with no
+// live attachment left are handed to the sweeper.
+func attachmentBlob(db *gorm.DB) error {
+	if err := db.Exec(`CREATE TABLE attachment_blob (
+		hash CHAR(64) NOT NULL PRIMARY KEY,
+		created_at DATETIME NOT NULL,
+		orphaned_at DATETIME NULL,
+		INDEX idx_attachment_blob_orphaned_at (orphaned_at)
+	)`).Error; err != nil {
+		return err
+	}
+
+	return db.Exec(`INSERT INTO attachment_blob (hash, created_at, orphaned_at)
+		SELECT hash, MIN(created_at), IF(SUM(deleted_at IS NULL) > 0, NULL, UTC_TIMESTAMP())
+		FROM attachment GROUP BY hash`).Error
+}
@@ -0,0 +1,81 @@
+package migration
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Migration struct {
+	ID string
+	Up func(db *gorm.DB) error
+}
+
+type SchemaMigration struct {
+	ID        string    `json:"id"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+func (t *SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrations are applied in slice order, append new ones at the end.
+var migrations = []Migration{
+	{ID: "0001_post_user_id", Up: postUserID},
+	{ID: "0002_post_pagination_index", Up: postPaginationIndex},
+	{ID: "0003_post_fulltext", Up: postFulltext},
+	{ID: "0004_post_revision", Up: postRevision},
+	{ID: "0005_post_status", Up: postStatus},
+	{ID: "0006_tag_category", Up: tagCategory},
+	{ID: "0007_comment", Up: comment},
+	{ID: "0008_post_reaction", Up: postReaction},
+	{ID: "0009_post_content_format", Up: postContentFormat},
+	{ID: "0010_post_slug", Up: postSlug},
+	{ID: "0011_attachment", Up: attachment},
+	{ID: "0012_user_avatar", Up: userAvatar},
+	{ID: "0013_post_trash", Up: postTrash},
+	{ID: "0014_post_version", Up: postVersion},
+	{ID: "0015_post_visibility", Up: postVisibility},
+	{ID: "0016_follow_timeline", Up: followTimeline},
+	{ID: "0017_bookmark", Up: bookmark},
+	{ID: "0018_notification", Up: notification},
+	{ID: "0019_user_handle_mention", Up: userHandleMention},
+	{ID: "0020_token_revocation", Up: tokenRevocation},
+	{ID: "0021_refresh_token_family", Up: refreshTokenFamily},
+	{ID: "0022_session", Up: session},
+	{ID: "0023_search_change", Up: searchChange},
+	{ID: "0024_attachment_blob", Up: attachmentBlob},
+}
+
+func Run(db *gorm.DB) error {
+	if err := db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (id VARCHAR(191) NOT NULL PRIMARY KEY, applied_at DATETIME NOT NULL)").Error; err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		var count int64
+
+		if err := db.Model(&SchemaMigration{}).Where("id = ?", m.ID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count > 0 {
+			continue
+		}
+
+		log.Println("migrate " + m.ID + "...")
+
+		if err := m.Up(db); err != nil {
+			return fmt.Errorf("migration %s: %w", m.ID, err)
+		}
+
+		if err := db.Create(&SchemaMigration{ID: m.ID, AppliedAt: time.Now().UTC()}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// Attachment is a file uploaded to a post. Files are stored once per content
+// hash, so several attachments can share the same stored object.
+type Attachment struct {
+	ID           int        `json:"id"`
+	PostID       int        `json:"post_id"`
+	UserID       int        `json:"user_id"`
+	FileName     string     `json:"file_name"`
+	ContentType  string     `json:"content_type"`
+	Size         int64      `json:"size"`
+	Hash         string     `json:"hash"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DeletedAt    *time.Time `json:"deleted_at"`
+	URL          string     `json:"url" gorm:"-"`
+	URLExpiresAt *time.Time `json:"url_expires_at" gorm:"-"`
+}
+
+// AttachmentBlob is a stored attachment file, shared by every attachment
+// with the same content. OrphanedAt is set while no live attachment uses it.
+type AttachmentBlob struct {
+	Hash       string     `json:"hash" gorm:"primaryKey"`
+	CreatedAt  time.Time  `json:"created_at"`
+	OrphanedAt *time.Time `json:"orphaned_at"`
+}
+
+type NewAttachment struct {
+	PostID   int
+	FileName string
+	Data     []byte
+}
+
+type AttachmentResponse struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Data    *Attachment `json:"data"`
+}
+
+type AttachmentMultipleResponse struct {
+	Success bool          `json:"success"`
+	Message string        `json:"message"`
+	Data    []*Attachment `json:"data"`
+}
@@ -0,0 +1,9 @@
+package model
+
+// AvatarImage is one size of a user avatar, either processed from an upload
+// or a generated identicon.
+type AvatarImage struct {
+	Data        []byte
+	ContentType string
+	ETag        string
+}
@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+type Bookmark struct {
+	UserID    int       `json:"user_id"`
+	PostID    int       `json:"post_id"`
+	FolderID  *int      `json:"folder_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BookmarkFolder is a named reading list of bookmarks.
+type BookmarkFolder struct {
+	ID            int       `json:"id"`
+	UserID        int       `json:"user_id"`
+	Name          string    `json:"name"`
+	CreatedAt     time.Time `json:"created_at"`
+	BookmarkCount int       `json:"bookmark_count" gorm:"->"`
+}
+
+type NewBookmarkFolder struct {
+	Name string `json:"name"`
+}
+
+// NewBookmark saves a post, or moves an existing bookmark to another folder.
+// A nil FolderID keeps the bookmark out of any folder.
+type NewBookmark struct {
+	PostID   int  `json:"post_id"`
+	FolderID *int `json:"folder_id"`
+}
+
+// BookmarkFilter lists every bookmark without FolderID, only those in the
+// folder with it and the ones in no folder with Unfiled.
+type BookmarkFilter struct {
+	FolderID *int   `form:"folder_id"`
+	Unfiled  bool   `form:"unfiled"`
+	Cursor   string `form:"cursor"`
+	Limit    int    `form:"limit"`
+}
+
+type BookmarkResponse struct {
+	Success bool      `json:"success"`
+	Message string    `json:"message"`
+	Data    *Bookmark `json:"data"`
+}
+
+type BookmarkFolderResponse struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    *BookmarkFolder `json:"data"`
+}
+
+type BookmarkFolderMultipleResponse struct {
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Data    []*BookmarkFolder `json:"data"`
+}
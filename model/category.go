@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+type Category struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type NewCategory struct {
+	Name string `json:"name"`
+}
+
+type CategoryCount struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	PostCount int    `json:"post_count"`
+}
+
+type CategoryResponse struct {
+	Success bool      `json:"success"`
+	Message string    `json:"message"`
+	Data    *Category `json:"data"`
+}
+
+type CategoryMultipleResponse struct {
+	Success bool             `json:"success"`
+	Message string           `json:"message"`
+	Data    []*CategoryCount `json:"data"`
+}
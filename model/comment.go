@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+type Comment struct {
+	ID        int         `json:"id"`
+	PostID    int         `json:"post_id"`
+	UserID    int         `json:"user_id"`
+	ParentID  *int        `json:"parent_id"`
+	Body      string      `json:"body"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt *time.Time  `json:"updated_at"`
+	DeletedAt *time.Time  `json:"deleted_at"`
+	Author    *PostAuthor `json:"author" gorm:"foreignKey:UserID"`
+	Replies   []*Comment  `json:"replies,omitempty" gorm:"-"`
+}
+
+type NewComment struct {
+	PostID   int    `json:"post_id"`
+	ParentID *int   `json:"parent_id"`
+	Body     string `json:"body"`
+}
+
+type UpdateComment struct {
+	ID   int    `json:"id"`
+	Body string `json:"body"`
+}
+
+type CommentFilter struct {
+	PostID int    `form:"id"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+}
+
+type CommentPage struct {
+	Comments   []*Comment
+	NextCursor string
+	HasMore    bool
+}
+
+type CommentResponse struct {
+	Success bool     `json:"success"`
+	Message string   `json:"message"`
+	Data    *Comment `json:"data"`
+}
+
+type CommentMultipleResponse struct {
+	Success    bool       `json:"success"`
+	Message    string     `json:"message"`
+	Data       []*Comment `json:"data"`
+	NextCursor string     `json:"next_cursor"`
+	HasMore    bool       `json:"has_more"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+const (
+	FeedFormatRSS  = "rss"
+	FeedFormatAtom = "atom"
+	FeedFormatJSON = "json"
+)
+
+// Feed is the format independent content of /feed.rss, /feed.atom and
+// /feed.json. Every URL in it is absolute.
+type Feed struct {
+	Title   string
+	HomeURL string
+	FeedURL string
+	Updated *time.Time
+	Items   []*FeedItem
+}
+
+type FeedItem struct {
+	ID          string
+	URL         string
+	Title       string
+	ContentHTML string
+	Author      string
+	Tags        []string
+	Published   time.Time
+	Updated     time.Time
+}
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+type Follow struct {
+	FollowerID int       `json:"follower_id"`
+	FolloweeID int       `json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TimelineEntry is a post copied into a follower's timeline, only used with
+// TIMELINE_STRATEGY=write.
+type TimelineEntry struct {
+	UserID      int       `json:"user_id"`
+	PostID      int       `json:"post_id"`
+	AuthorID    int       `json:"author_id"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// FollowUser is a user in a follower or following list.
+type FollowUser struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	AvatarURL  string    `json:"avatar_url"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type FollowStats struct {
+	UserID       int  `json:"user_id"`
+	Followers    int  `json:"followers"`
+	Following    int  `json:"following"`
+	FollowedByMe bool `json:"followed_by_me"`
+}
+
+type FollowFilter struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+}
+
+type FollowUserPage struct {
+	Users      []*FollowUser
+	NextCursor string
+	HasMore    bool
+}
+
+type FollowUserMultipleResponse struct {
+	Success    bool          `json:"success"`
+	Message    string        `json:"message"`
+	Data       []*FollowUser `json:"data"`
+	NextCursor string        `json:"next_cursor"`
+	HasMore    bool          `json:"has_more"`
+}
+
+type FollowStatsResponse struct {
+	Success bool         `json:"success"`
+	Message string       `json:"message"`
+	Data    *FollowStats `json:"data"`
+}
+
+type TimelineFilter struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+}
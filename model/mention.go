@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// PostMention records that a post mentions a user by @handle.
+type PostMention struct {
+	PostID    int       `json:"post_id"`
+	UserID    int       `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MentionFilter struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+}
@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+const (
+	NotificationTypePostDeleted      = "post_deleted"
+	NotificationTypeLogin            = "login"
+	NotificationTypeRefreshNewClient = "refresh_new_client"
+	NotificationTypeRefreshReuse     = "refresh_reuse"
+)
+
+type Notification struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	Type      string     `json:"type"`
+	Message   string     `json:"message"`
+	ActorID   *int       `json:"actor_id"`
+	PostID    *int       `json:"post_id"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NewNotification is what a producer hands over, ActorID is the user who
+// caused it when there is one.
+type NewNotification struct {
+	UserID  int
+	Type    string
+	Message string
+	ActorID *int
+	PostID  *int
+}
+
+type NotificationFilter struct {
+	Unread bool   `form:"unread"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+}
+
+type NotificationPage struct {
+	Notifications []*Notification
+	NextCursor    string
+	HasMore       bool
+}
+
+type NotificationUnread struct {
+	Unread int `json:"unread"`
+}
+
+type NotificationMultipleResponse struct {
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Data       []*Notification `json:"data"`
+	NextCursor string          `json:"next_cursor"`
+	HasMore    bool            `json:"has_more"`
+}
+
+type NotificationUnreadResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Data    *NotificationUnread `json:"data"`
+}
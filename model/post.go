@@ -2,34 +2,168 @@ package model
 
 import "time"
 
+const (
+	PostContentFormatPlain    = "plain"
+	PostContentFormatMarkdown = "markdown"
+)
+
+// Unlisted posts can be read by anyone with the link but are left out of
+// listings, search and feeds. Private posts are only visible to their author.
+const (
+	PostVisibilityPublic   = "public"
+	PostVisibilityUnlisted = "unlisted"
+	PostVisibilityPrivate  = "private"
+)
+
+const (
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
+	PostStatusPublished = "published"
+	PostStatusArchived  = "archived"
+)
+
 type Post struct {
-	ID        int        `json:"id"`
-	Title     string     `json:"title"`
-	Content   string     `json:"content"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at"`
-	DeletedAt *time.Time `json:"deleted_at"`
+	ID            int                `json:"id"`
+	UserID        int                `json:"user_id"`
+	Slug          string             `json:"slug"`
+	Title         string             `json:"title"`
+	Content       string             `json:"content"`
+	ContentFormat string             `json:"content_format"`
+	ContentHTML   string             `json:"content_html,omitempty"`
+	Status        string             `json:"status"`
+	Visibility    string             `json:"visibility"`
+	PublishAt     *time.Time         `json:"publish_at"`
+	Version       int                `json:"version"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     *time.Time         `json:"updated_at"`
+	DeletedAt     *time.Time         `json:"deleted_at"`
+	PurgeAt       *time.Time         `json:"purge_at,omitempty" gorm:"-"`
+	CommentCount  int                `json:"comment_count" gorm:"->"`
+	Author        *PostAuthor        `json:"author" gorm:"foreignKey:UserID"`
+	Tags          []*Tag             `json:"tags" gorm:"many2many:post_tag"`
+	Categories    []*Category        `json:"categories" gorm:"many2many:post_category"`
+	Reactions     []*ReactionSummary `json:"reactions" gorm:"-"`
+	Bookmarked    bool               `json:"bookmarked" gorm:"-"`
+}
+
+// PostSlugRedirect keeps a slug a post used before its title changed.
+type PostSlugRedirect struct {
+	ID        int       `json:"id"`
+	PostID    int       `json:"post_id"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SearchChange records that a post was indexed or removed from search.
+type SearchChange struct {
+	ID        int64     `json:"id"`
+	PostID    int       `json:"post_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PostAuthor struct {
+	ID     int     `json:"id"`
+	Name   string  `json:"name"`
+	Handle *string `json:"handle"`
 }
 
 type NewPost struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title         string     `json:"title"`
+	Content       string     `json:"content"`
+	ContentFormat string     `json:"content_format"`
+	Status        string     `json:"status"`
+	Visibility    string     `json:"visibility"`
+	PublishAt     *time.Time `json:"publish_at"`
+	Tags          []string   `json:"tags"`
+	Categories    []string   `json:"categories"`
 }
 
+// UpdatePost leaves tags/categories untouched when they are omitted, an empty
+// list clears them.
 type UpdatePost struct {
-	ID      int    `json:"id"`
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	ID            int        `json:"id"`
+	Title         string     `json:"title"`
+	Content       string     `json:"content"`
+	ContentFormat string     `json:"content_format"`
+	Status        string     `json:"status"`
+	Visibility    string     `json:"visibility"`
+	PublishAt     *time.Time `json:"publish_at"`
+	Tags          []string   `json:"tags"`
+	Categories    []string   `json:"categories"`
 }
 
+// PostPatchDocument is the editable part of a post. PATCH /post/:id applies
+// merge patches and JSON patches to this document. Tags are names, categories
+// are slugs.
+type PostPatchDocument struct {
+	Title         string     `json:"title"`
+	Content       string     `json:"content"`
+	ContentFormat string     `json:"content_format"`
+	Status        string     `json:"status"`
+	Visibility    string     `json:"visibility"`
+	PublishAt     *time.Time `json:"publish_at"`
+	Tags          []string   `json:"tags"`
+	Categories    []string   `json:"categories"`
+}
+
+const (
+	PostPatchMerge = "application/merge-patch+json"
+	PostPatchJSON  = "application/json-patch+json"
+)
+
 type PostResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
 	Data    *Post  `json:"data"`
 }
 
+type PostFilter struct {
+	Cursor        string     `form:"cursor"`
+	Limit         int        `form:"limit"`
+	Sort          string     `form:"sort"`
+	Order         string     `form:"order"`
+	Title         string     `form:"title"`
+	HTML          bool       `form:"html"`
+	Tag           string     `form:"tag"`
+	Category      string     `form:"category"`
+	CreatedBefore *time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedAfter  *time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type PostTrashFilter struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+}
+
+type PostPage struct {
+	Posts      []*Post
+	NextCursor string
+	HasMore    bool
+}
+
 type PostMultipleResponse struct {
-	Success bool    `json:"success"`
-	Message string  `json:"message"`
-	Data    []*Post `json:"data"`
+	Success    bool    `json:"success"`
+	Message    string  `json:"message"`
+	Data       []*Post `json:"data"`
+	NextCursor string  `json:"next_cursor"`
+	HasMore    bool    `json:"has_more"`
+}
+
+type PostSearchInput struct {
+	Query  string `form:"q"`
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
+}
+
+type PostSearchHit struct {
+	Post             *Post   `json:"post"`
+	Score            float64 `json:"score"`
+	TitleHighlight   string  `json:"title_highlight"`
+	ContentHighlight string  `json:"content_highlight"`
+}
+
+type PostSearchResponse struct {
+	Success bool             `json:"success"`
+	Message string           `json:"message"`
+	Data    []*PostSearchHit `json:"data"`
 }
@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+const (
+	PostExportJSONL = "jsonl"
+	PostExportCSV   = "csv"
+)
+
+// PostExportRow is one post in an export file, and the row format imports
+// accept. content_html is exported for completeness but re-rendered on
+// import, id and version are ignored on import.
+type PostExportRow struct {
+	ID            int        `json:"id"`
+	UserID        int        `json:"user_id"`
+	Slug          string     `json:"slug"`
+	Title         string     `json:"title"`
+	Content       string     `json:"content"`
+	ContentFormat string     `json:"content_format"`
+	ContentHTML   string     `json:"content_html"`
+	Status        string     `json:"status"`
+	Visibility    string     `json:"visibility"`
+	PublishAt     *time.Time `json:"publish_at"`
+	Version       int        `json:"version"`
+	CreatedAt     *time.Time `json:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+	DeletedAt     *time.Time `json:"deleted_at"`
+	Tags          []string   `json:"tags"`
+	Categories    []string   `json:"categories"`
+}
+
+// PostExportFilter selects the posts to export. UserID 0 exports every
+// author, it is only settable from the command line.
+type PostExportFilter struct {
+	Format         string `form:"format"`
+	IncludeDeleted bool   `form:"include_deleted"`
+	UserID         int    `form:"-"`
+}
+
+type PostImportError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+type PostImportResult struct {
+	Imported int                `json:"imported"`
+	Failed   int                `json:"failed"`
+	Errors   []*PostImportError `json:"errors"`
+}
+
+type PostImportResponse struct {
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Data    *PostImportResult `json:"data"`
+}
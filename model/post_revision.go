@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// PostRevision is the state of a post right before an update replaced it.
+type PostRevision struct {
+	ID            int       `json:"id"`
+	PostID        int       `json:"post_id"`
+	EditorID      int       `json:"editor_id"`
+	Title         string    `json:"title"`
+	Content       string    `json:"content"`
+	ContentFormat string    `json:"content_format"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type PostRevisionRestore struct {
+	PostID     int `json:"post_id"`
+	RevisionID int `json:"revision_id"`
+}
+
+type PostRevisionDiff struct {
+	PostID int    `json:"post_id"`
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+	Diff   string `json:"diff"`
+}
+
+type PostRevisionMultipleResponse struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    []*PostRevision `json:"data"`
+}
+
+type PostRevisionDiffResponse struct {
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Data    *PostRevisionDiff `json:"data"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+const (
+	ReactionLike  = "like"
+	ReactionLove  = "love"
+	ReactionLaugh = "laugh"
+	ReactionWow   = "wow"
+	ReactionSad   = "sad"
+	ReactionAngry = "angry"
+)
+
+var ReactionTypes = []string{
+	ReactionLike,
+	ReactionLove,
+	ReactionLaugh,
+	ReactionWow,
+	ReactionSad,
+	ReactionAngry,
+}
+
+type PostReaction struct {
+	PostID    int       `json:"post_id"`
+	UserID    int       `json:"user_id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PostReactionCount is the denormalized number of reactions of one type on a
+// post, kept in step with post_reaction on every write.
+type PostReactionCount struct {
+	PostID int    `json:"post_id"`
+	Type   string `json:"type"`
+	Count  int    `json:"count"`
+}
+
+type ReactionSummary struct {
+	Type        string `json:"type"`
+	Count       int    `json:"count"`
+	ReactedByMe bool   `json:"reacted_by_me"`
+}
+
+type ReactionSummaryResponse struct {
+	Success bool               `json:"success"`
+	Message string             `json:"message"`
+	Data    []*ReactionSummary `json:"data"`
+}
@@ -3,12 +3,18 @@ package model
 import "time"
 
 type RefreshTokens struct {
-	ID        int        `json:"id"`
-	UserID    int        `json:"user_id"`
-	TokenHash string     `json:"token_hash"`
-	CreatedAt time.Time  `json:"created_at"`
-	ExpiredAt time.Time  `json:"expired_at"`
-	RevokedAt *time.Time `json:"revoked_at"`
+	ID           int        `json:"id"`
+	UserID       int        `json:"user_id"`
+	FamilyID     int        `json:"family_id"`
+	ReplacedByID *int       `json:"replaced_by_id"`
+	TokenHash    string     `json:"token_hash"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiredAt    time.Time  `json:"expired_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	UserAgent    string     `json:"user_agent"`
+	IPAddress    string     `json:"ip_address"`
+	DeviceLabel  string     `json:"device_label"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
 }
 
 type RefreshTokenInput struct {
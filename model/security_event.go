@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+const (
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse"
+)
+
+// SecurityEvent is an audit record of something suspicious happening to an
+// account.
+type SecurityEvent struct {
+	ID                   int       `json:"id"`
+	UserID               int       `json:"user_id"`
+	Type                 string    `json:"type"`
+	RefreshTokenFamilyID *int      `json:"refresh_token_family_id"`
+	UserAgent            string    `json:"user_agent"`
+	IPAddress            string    `json:"ip_address"`
+	CreatedAt            time.Time `json:"created_at"`
+}
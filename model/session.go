@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Session is a login on one device. It lives as long as its refresh token
+// family and is identified by the family id.
+type Session struct {
+	ID          int        `json:"id"`
+	DeviceLabel string     `json:"device_label"`
+	UserAgent   string     `json:"user_agent"`
+	IPAddress   string     `json:"ip_address"`
+	SignedInAt  time.Time  `json:"signed_in_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	ExpiredAt   time.Time  `json:"expired_at"`
+	Current     bool       `json:"current"`
+}
+
+type SessionMultipleResponse struct {
+	Success bool       `json:"success"`
+	Message string     `json:"message"`
+	Data    []*Session `json:"data"`
+}
@@ -8,6 +8,42 @@ func (t *Post) TableName() string {
 	return "post"
 }
 
+func (t *PostSlugRedirect) TableName() string {
+	return "post_slug_redirect"
+}
+
+func (t *PostAuthor) TableName() string {
+	return "user"
+}
+
+func (t *PostRevision) TableName() string {
+	return "post_revision"
+}
+
+func (t *Tag) TableName() string {
+	return "tag"
+}
+
+func (t *Category) TableName() string {
+	return "category"
+}
+
+func (t *Comment) TableName() string {
+	return "comment"
+}
+
+func (t *PostReaction) TableName() string {
+	return "post_reaction"
+}
+
+func (t *PostReactionCount) TableName() string {
+	return "post_reaction_count"
+}
+
+func (t *Attachment) TableName() string {
+	return "attachment"
+}
+
 func (t *User) TableName() string {
 	return "user"
 }
@@ -15,3 +51,39 @@ func (t *User) TableName() string {
 func (t *RefreshTokens) TableName() string {
 	return "refresh_tokens"
 }
+
+func (t *Follow) TableName() string {
+	return "follow"
+}
+
+func (t *TimelineEntry) TableName() string {
+	return "timeline_entry"
+}
+
+func (t *Bookmark) TableName() string {
+	return "bookmark"
+}
+
+func (t *BookmarkFolder) TableName() string {
+	return "bookmark_folder"
+}
+
+func (t *Notification) TableName() string {
+	return "notification"
+}
+
+func (t *PostMention) TableName() string {
+	return "post_mention"
+}
+
+func (t *SecurityEvent) TableName() string {
+	return "security_event"
+}
+
+func (t *SearchChange) TableName() string {
+	return "search_change"
+}
+
+func (t *AttachmentBlob) TableName() string {
+	return "attachment_blob"
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+type Tag struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TagCount struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	PostCount int    `json:"post_count"`
+}
+
+type TagFilter struct {
+	Limit  int `form:"limit"`
+	Offset int `form:"offset"`
+}
+
+type TagPage struct {
+	Tags    []*TagCount
+	HasMore bool
+}
+
+type TagMultipleResponse struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Data    []*TagCount `json:"data"`
+	HasMore bool        `json:"has_more"`
+}
@@ -2,34 +2,58 @@ package model
 
 import "time"
 
+const (
+	AvatarStatusNone       = "none"
+	AvatarStatusProcessing = "processing"
+	AvatarStatusReady      = "ready"
+	AvatarStatusFailed     = "failed"
+)
+
 type User struct {
-	ID        int        `json:"id"`
-	Name      string     `json:"name"`
-	Email     string     `json:"email"`
-	Password  string     `json:"password"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at"`
-	DeletedAt *time.Time `json:"deleted_at"`
+	ID                int        `json:"id"`
+	Name              string     `json:"name"`
+	Handle            *string    `json:"handle"`
+	Email             string     `json:"email"`
+	Password          string     `json:"password"`
+	AvatarStatus      string     `json:"avatar_status"`
+	AvatarHash        *string    `json:"avatar_hash"`
+	AvatarPendingHash *string    `json:"avatar_pending_hash"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         *time.Time `json:"updated_at"`
+	DeletedAt         *time.Time `json:"deleted_at"`
 }
 
 type UserData struct {
-	ID        int        `json:"id"`
-	Name      string     `json:"name"`
-	Email     string     `json:"email"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at"`
-	DeletedAt *time.Time `json:"deleted_at"`
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	Handle       *string    `json:"handle"`
+	Email        string     `json:"email"`
+	AvatarStatus string     `json:"avatar_status"`
+	AvatarURL    string     `json:"avatar_url"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
+	DeletedAt    *time.Time `json:"deleted_at"`
 }
 
 type NewUser struct {
 	Name     string `json:"name"`
+	Handle   string `json:"handle"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
+// UserHandleInput sets the handle others @mention a user by.
+type UserHandleInput struct {
+	Handle string `json:"handle"`
+}
+
 type UserLogin struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+
+	// DeviceLabel names the session in the session list, it is guessed
+	// from the user agent when empty.
+	DeviceLabel string `json:"device_label"`
 }
 
 type UserResponse struct {
@@ -12,18 +12,80 @@ import (
 func ApiRouter(r *gin.Engine) {
 	r.POST("/auth/refresh", controller.AuthRefreshToken)
 
-	r.POST("/post", controller.PostCreate)
-	r.PUT("/post", controller.PostUpdate)
-	r.DELETE("/post", controller.PostDelete)
 	r.GET("/posts", controller.PostGetAll)
+	r.GET("/posts/search", controller.PostSearch)
+	r.GET("/posts/by-slug/:slug", controller.PostGetBySlug)
+	r.GET("/post/comments", controller.CommentGetAll)
+	r.GET("/post/comments/tree", controller.CommentGetTree)
+	r.GET("/post/attachments", controller.AttachmentGetAll)
+	r.GET("/attachments/:id/download", controller.AttachmentDownload)
+	r.GET("/tags", controller.TagGetAll)
+	r.GET("/categories", controller.CategoryGetAll)
 	r.GET("/post", controller.PostGetByID)
 
+	r.GET("/feed.rss", controller.FeedRSS)
+	r.GET("/feed.atom", controller.FeedAtom)
+	r.GET("/feed.json", controller.FeedJSON)
+
 	r.POST("/user/register", controller.UserRegister)
 	r.POST("/user/login", controller.UserLogin)
+	r.GET("/users/:id/avatar", controller.UserAvatarGet)
+	r.GET("/users/:id/follow", controller.FollowStats)
+	r.GET("/users/:id/followers", controller.FollowGetFollowers)
+	r.GET("/users/:id/following", controller.FollowGetFollowing)
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	authRoute := r.Group("")
 	authRoute.Use(middleware.IsLogin())
+	authRoute.POST("/auth/logout", controller.AuthLogout)
+	authRoute.POST("/auth/logout-all", controller.AuthLogoutAll)
 	authRoute.GET("/user/me", controller.UserGetMe)
+	authRoute.POST("/user/avatar", controller.UserAvatarUpload)
+	authRoute.DELETE("/user/avatar", controller.UserAvatarDelete)
+	authRoute.PUT("/user/handle", controller.UserSetHandle)
+	authRoute.GET("/user/mentions", controller.MentionGetAll)
+	authRoute.GET("/user/sessions", controller.SessionGetAll)
+	authRoute.DELETE("/user/sessions/:id", controller.SessionDelete)
+	authRoute.GET("/user/trash", controller.PostGetTrash)
+	authRoute.PUT("/users/:id/follow", controller.FollowCreate)
+	authRoute.DELETE("/users/:id/follow", controller.FollowDelete)
+	authRoute.GET("/timeline", controller.TimelineGet)
+	authRoute.GET("/user/bookmarks", controller.BookmarkGetAll)
+	authRoute.GET("/user/bookmark-folders", controller.BookmarkFolderGetAll)
+	authRoute.POST("/user/bookmark-folder", controller.BookmarkFolderCreate)
+	authRoute.DELETE("/user/bookmark-folder", controller.BookmarkFolderDelete)
+	authRoute.GET("/notifications", controller.NotificationGetAll)
+	authRoute.GET("/notifications/unread", controller.NotificationUnread)
+	authRoute.GET("/notifications/stream", controller.NotificationStream)
+	authRoute.POST("/notifications/read", controller.NotificationMarkRead)
+	authRoute.POST("/notifications/read-all", controller.NotificationMarkAllRead)
+
+	authRoute.POST("/post", controller.PostCreate)
+	authRoute.PUT("/post", controller.PostUpdate)
+	authRoute.PATCH("/post/:id", controller.PostPatch)
+	authRoute.GET("/posts/export", controller.PostExport)
+	authRoute.POST("/posts/import", controller.PostImport)
+	authRoute.DELETE("/post", controller.PostDelete)
+	authRoute.POST("/post/restore", controller.PostRestore)
+	authRoute.GET("/post/revisions", controller.PostRevisionGetAll)
+	authRoute.GET("/post/revisions/diff", controller.PostRevisionDiff)
+	authRoute.POST("/post/revisions/restore", controller.PostRevisionRestore)
+
+	authRoute.PUT("/post/reaction", controller.PostReactionPut)
+	authRoute.DELETE("/post/reaction", controller.PostReactionDelete)
+	authRoute.PUT("/post/bookmark", controller.BookmarkPut)
+	authRoute.DELETE("/post/bookmark", controller.BookmarkDelete)
+
+	authRoute.POST("/post/attachments", controller.AttachmentCreate)
+	authRoute.DELETE("/attachment", controller.AttachmentDelete)
+
+	authRoute.POST("/comment", controller.CommentCreate)
+	authRoute.PUT("/comment", controller.CommentUpdate)
+	authRoute.DELETE("/comment", controller.CommentDelete)
+
+	operatorRoute := authRoute.Group("")
+	operatorRoute.Use(middleware.IsOperator())
+	operatorRoute.POST("/category", controller.CategoryCreate)
+	operatorRoute.DELETE("/post/purge", controller.PostPurge)
 }
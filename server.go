@@ -5,7 +5,10 @@ import (
 	"myapp/config"
 	"myapp/docs"
 	"myapp/middleware"
+	"myapp/migration"
 	"myapp/router"
+	"myapp/service"
+	"myapp/storage"
 	"os"
 
 	"github.com/gin-gonic/gin"
@@ -24,21 +27,45 @@ func main() {
 	sqlDB, _ := db.DB()
 	defer sqlDB.Close()
 
+	if err := migration.Run(db); err != nil {
+		panic(err)
+	}
+
+	storage.Init()
+	service.SearchInit()
+
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	service.StartPostPublisher(config.GetPublisherInterval())
+	service.StartPostTrashPurger(config.GetTrashPurgeInterval(), config.GetTrashRetention())
+	service.StartSearchRefresher(config.GetSearchRefreshInterval())
+	service.StartAttachmentSweeper(config.GetAttachmentSweepInterval())
+	service.StartAvatarWorkers(2)
+	service.StartNotificationHub(config.GetNotificationPollInterval())
+
 	docs.SwaggerInfo.Title = "Posting API"
 	docs.SwaggerInfo.Description = "API docs for posting"
 	docs.SwaggerInfo.Version = "1.0"
-	docs.SwaggerInfo.Host = "localhost:8080"
-	docs.SwaggerInfo.Schemes = []string{"http", "https"}
+	publicURL := config.GetPublicURL()
+	docs.SwaggerInfo.Host = publicURL.Host
+	docs.SwaggerInfo.BasePath = publicURL.Path
+	docs.SwaggerInfo.Schemes = []string{publicURL.Scheme}
 
 	r := gin.New()
 	r.Use(
 		gin.Recovery(),
 		middleware.CORSMiddleware(),
+		middleware.ClientMiddleware(),
 		middleware.AuthMiddleware(),
 	)
 
 	router.ApiRouter(r)
 
-	log.Println("Listen and serve at http://localhost:" + port)
+	log.Println("Listen and serve at " + publicURL.String() + " on port " + port)
 	r.Run(":" + port)
 }
@@ -23,7 +23,7 @@ func (s *Service) AccessTokensCheckExistByRawToken(ctx context.Context, token st
 
 	hashedToken := tools.HashSHA256(token)
 
-	if err := s.DB.Model(&accessToken).Where("token_hash = ? AND expired_at > ? AND revoked_at IS NULL", hashedToken, time.Now().UTC()).Count(&count).Error; err != nil {
+	if err := s.DB.Model(&accessToken).Scopes(tools.IsAccessTokenActive).Where("token_hash = ?", hashedToken).Count(&count).Error; err != nil {
 		panic(err)
 	}
 
@@ -33,3 +33,15 @@ func (s *Service) AccessTokensCheckExistByRawToken(ctx context.Context, token st
 
 	return false, nil
 }
+
+func (s *Service) AccessTokensRevokeByUserID(ctx context.Context, userID int) error {
+	var (
+		accessToken model.AccessTokens
+	)
+
+	if err := s.DB.Model(&accessToken).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", time.Now().UTC()).Error; err != nil {
+		panic(err)
+	}
+
+	return nil
+}
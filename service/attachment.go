@@ -0,0 +1,384 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"myapp/config"
+	"myapp/middleware"
+	"myapp/model"
+	"myapp/storage"
+	"myapp/tools"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	attachmentFileNameMaxLength = 255
+
+	// attachmentOrphanGrace is how long a stored file no attachment uses is
+	// kept before the sweeper deletes it. It covers uploads whose
+	// transaction is still running or was rolled back.
+	attachmentOrphanGrace = time.Hour
+
+	attachmentSweepBatchSize = 100
+)
+
+// attachmentAllowedTypes is checked against the sniffed content type, never
+// the one sent by the client.
+var attachmentAllowedTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+func (s *Service) AttachmentCreate(ctx context.Context, input model.NewAttachment) (*model.Attachment, error) {
+	var (
+		getUser  = middleware.AuthContext(ctx)
+		existing model.Attachment
+	)
+
+	s.PostGetOwnedByID(ctx, input.PostID)
+
+	if len(input.Data) == 0 {
+		panic(tools.NewCustomError(400, "Empty file"))
+	}
+
+	if int64(len(input.Data)) > config.GetAttachmentMaxSize() {
+		panic(tools.NewCustomError(413, fmt.Sprintf("File is larger than %d bytes", config.GetAttachmentMaxSize())))
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(input.Data))
+	if !attachmentAllowedTypes[contentType] {
+		panic(tools.NewCustomError(415, "File type "+contentType+" is not allowed"))
+	}
+
+	sum := sha256.Sum256(input.Data)
+	hash := hex.EncodeToString(sum[:])
+
+	// The same file uploaded twice to a post is the same attachment.
+	err := s.DB.Model(&existing).Scopes(tools.IsDeletedAtNull).Where("post_id = ? AND hash = ?", input.PostID, hash).First(&existing).Error
+	if err == nil {
+		s.attachmentSignURL(&existing)
+		return &existing, nil
+	} else if err != gorm.ErrRecordNotFound {
+		panic(err)
+	}
+
+	s.attachmentBlobAcquire(hash)
+
+	if err := attachmentPut(ctx, storage.GetStorage(), attachmentKey(hash), input.Data, contentType); err != nil {
+		panic(err)
+	}
+
+	attachment := model.Attachment{
+		PostID:      input.PostID,
+		UserID:      getUser.ID,
+		FileName:    attachmentCleanFileName(input.FileName),
+		ContentType: contentType,
+		Size:        int64(len(input.Data)),
+		Hash:        hash,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	if err := s.DB.Model(&attachment).Create(&attachment).Error; err != nil {
+		panic(err)
+	}
+
+	s.attachmentSignURL(&attachment)
+
+	return &attachment, nil
+}
+
+func (s *Service) AttachmentGetAllByPostID(ctx context.Context, postID int) ([]*model.Attachment, error) {
+	var (
+		attachments []*model.Attachment
+	)
+
+	s.PostGetByID(ctx, postID)
+
+	if err := s.DB.Model(&attachments).Scopes(tools.IsDeletedAtNull).Where("post_id = ?", postID).Order("id").Find(&attachments).Error; err != nil {
+		panic(err)
+	}
+
+	for _, attachment := range attachments {
+		s.attachmentSignURL(attachment)
+	}
+
+	return attachments, nil
+}
+
+func (s *Service) AttachmentGetByID(ctx context.Context, id int) (*model.Attachment, error) {
+	var (
+		attachment model.Attachment
+	)
+
+	if err := s.DB.Model(&attachment).Scopes(tools.IsDeletedAtNull).Where("id = ?", id).First(&attachment).Error; err == gorm.ErrRecordNotFound {
+		panic(tools.NewCustomError(404, "attachment not found"))
+	} else if err != nil {
+		panic(err)
+	}
+
+	return &attachment, nil
+}
+
+// AttachmentGetSigned checks a download URL signature before returning the
+// attachment. The signature stands in for authentication, so anyone holding
+// the URL can download until it expires.
+func (s *Service) AttachmentGetSigned(ctx context.Context, id int, expires int64, signature string) (*model.Attachment, error) {
+	if time.Now().Unix() > expires || !tools.VerifyHMAC(config.GetAttachmentURLKey(), attachmentSignMessage(id, expires), signature) {
+		panic(tools.NewCustomError(403, "Invalid or expired download link"))
+	}
+
+	return s.AttachmentGetByID(ctx, id)
+}
+
+func (s *Service) AttachmentOpen(ctx context.Context, attachment *model.Attachment) (io.ReadCloser, error) {
+	body, err := storage.GetStorage().Get(ctx, attachmentKey(attachment.Hash))
+	if err == storage.ErrNotFound {
+		panic(tools.NewCustomError(404, "attachment not found"))
+	} else if err != nil {
+		panic(err)
+	}
+
+	return body, nil
+}
+
+// AttachmentDeleteByID soft deletes an attachment of the logged in user. The
+// stored file is handed to the sweeper with the last attachment using it.
+func (s *Service) AttachmentDeleteByID(ctx context.Context, id int) (string, error) {
+	var (
+		getUser    = middleware.AuthContext(ctx)
+		attachment model.Attachment
+	)
+
+	current, _ := s.AttachmentGetByID(ctx, id)
+	if current.UserID != getUser.ID {
+		panic(tools.NewCustomError(403, "You are not the owner of this attachment"))
+	}
+
+	if err := s.DB.Model(&attachment).Scopes(tools.IsDeletedAtNull).Where("id = ?", id).Update("deleted_at", time.Now().UTC()).Error; err != nil {
+		panic(err)
+	}
+
+	s.attachmentBlobRelease(current.Hash)
+
+	return "Success", nil
+}
+
+// attachmentPut stores data under key unless a file with the same content is
+// already there.
+func attachmentPut(ctx context.Context, store storage.Storage, key string, data []byte, contentType string) error {
+	exists, err := store.Exists(ctx, key)
+	if err != nil || exists {
+		return err
+	}
+
+	return store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+}
+
+// attachmentBlobAcquire marks the stored file hash in use and holds its row
+// lock until the transaction ends, so it cannot be released or swept in the
+// meantime. The row is first registered outside the transaction as orphaned:
+// should the upload roll back, the file it stored is still found by the
+// sweeper. An orphaned row has its grace restarted there, so it cannot be
+// swept before the lock is taken.
+func (s *Service) attachmentBlobAcquire(hash string) {
+	var (
+		blob model.AttachmentBlob
+		now  = time.Now().UTC()
+	)
+
+	if err := GetService().DB.Exec(`INSERT INTO attachment_blob (hash, created_at, orphaned_at) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE orphaned_at = IF(orphaned_at IS NULL, NULL, VALUES(orphaned_at))`, hash, now, now).Error; err != nil {
+		panic(err)
+	}
+
+	if err := s.DB.Model(&blob).Clauses(clause.Locking{Strength: "UPDATE"}).Where("hash = ?", hash).Take(&blob).Error; err != nil {
+		panic(err)
+	}
+
+	if err := s.DB.Model(&blob).Where("hash = ?", hash).Update("orphaned_at", nil).Error; err != nil {
+		panic(err)
+	}
+}
+
+// attachmentBlobRelease hands the stored file hash to the sweeper once no
+// live attachment uses it. Attachments are counted with a locking read, which
+// sees uploads committed since the transaction started.
+func (s *Service) attachmentBlobRelease(hash string) {
+	var (
+		blob model.AttachmentBlob
+	)
+
+	if err := s.DB.Model(&blob).Clauses(clause.Locking{Strength: "UPDATE"}).Where("hash = ?", hash).Take(&blob).Error; err == gorm.ErrRecordNotFound {
+		return
+	} else if err != nil {
+		panic(err)
+	}
+
+	if s.attachmentBlobUsed(hash) {
+		return
+	}
+
+	if err := s.DB.Model(&blob).Where("hash = ?", hash).Update("orphaned_at", time.Now().UTC()).Error; err != nil {
+		panic(err)
+	}
+}
+
+func (s *Service) attachmentBlobUsed(hash string) bool {
+	var (
+		attachment model.Attachment
+		count      int64
+	)
+
+	if err := s.DB.Model(&attachment).Clauses(clause.Locking{Strength: "SHARE"}).Scopes(tools.IsDeletedAtNull).Where("hash = ?", hash).Count(&count).Error; err != nil {
+		panic(err)
+	}
+
+	return count > 0
+}
+
+// StartAttachmentSweeper deletes stored files that have been orphaned for
+// longer than attachmentOrphanGrace, checking every interval. Each file is
+// swept in its own transaction under the row lock, so several instances can
+// run it side by side.
+func StartAttachmentSweeper(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			runAttachmentSweeper()
+		}
+	}()
+}
+
+func runAttachmentSweeper() {
+	var (
+		blob   model.AttachmentBlob
+		hashes []string
+		count  int
+		cutoff = time.Now().UTC().Add(-attachmentOrphanGrace)
+	)
+
+	if err := config.GetDB().Model(&blob).Where("orphaned_at < ?", cutoff).Order("orphaned_at").Limit(attachmentSweepBatchSize).Pluck("hash", &hashes).Error; err != nil {
+		log.Println("attachment sweeper:", err)
+		return
+	}
+
+	for _, hash := range hashes {
+		if runAttachmentSweep(hash, cutoff) {
+			count++
+		}
+	}
+
+	if count > 0 {
+		log.Printf("attachment sweeper: deleted %d files", count)
+	}
+}
+
+func runAttachmentSweep(hash string, cutoff time.Time) (swept bool) {
+	s := GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			log.Println("attachment sweeper:", err)
+		}
+	}()
+
+	swept, _ = s.AttachmentBlobSweep(context.Background(), hash, cutoff)
+	s.Commit()
+
+	return swept
+}
+
+// AttachmentBlobSweep deletes the stored file hash and its row if it is still
+// orphaned since before orphanedBefore. The file goes first, with the row
+// locked: when the commit fails the row stays and the next sweep retries.
+func (s *Service) AttachmentBlobSweep(ctx context.Context, hash string, orphanedBefore time.Time) (bool, error) {
+	var (
+		blob model.AttachmentBlob
+	)
+
+	if err := s.DB.Model(&blob).Clauses(clause.Locking{Strength: "UPDATE"}).Where("hash = ? AND orphaned_at < ?", hash, orphanedBefore).Take(&blob).Error; err == gorm.ErrRecordNotFound {
+		return false, nil
+	} else if err != nil {
+		panic(err)
+	}
+
+	if s.attachmentBlobUsed(hash) {
+		if err := s.DB.Model(&blob).Where("hash = ?", hash).Update("orphaned_at", nil).Error; err != nil {
+			panic(err)
+		}
+
+		return false, nil
+	}
+
+	if err := storage.GetStorage().Delete(ctx, attachmentKey(hash)); err != nil {
+		panic(err)
+	}
+
+	if err := s.DB.Where("hash = ?", hash).Delete(&blob).Error; err != nil {
+		panic(err)
+	}
+
+	return true, nil
+}
+
+func (s *Service) attachmentSignURL(attachment *model.Attachment) {
+	expiresAt := time.Now().UTC().Add(config.GetAttachmentURLTTL()).Truncate(time.Second)
+	expires := expiresAt.Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", tools.SignHMAC(config.GetAttachmentURLKey(), attachmentSignMessage(attachment.ID, expires)))
+
+	attachment.URL = fmt.Sprintf("/attachments/%d/download?%s", attachment.ID, query.Encode())
+	attachment.URLExpiresAt = &expiresAt
+}
+
+func attachmentSignMessage(id int, expires int64) string {
+	return fmt.Sprintf("attachment:%d:%d", id, expires)
+}
+
+func attachmentKey(hash string) string {
+	return "attachments/" + hash[:2] + "/" + hash
+}
+
+func attachmentCleanFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+
+	if name == "." || name == "/" || name == "" {
+		name = "file"
+	}
+
+	// Keep the end of long names, it holds the extension.
+	if runes := []rune(name); len(runes) > attachmentFileNameMaxLength {
+		name = string(runes[len(runes)-attachmentFileNameMaxLength:])
+	}
+
+	return name
+}
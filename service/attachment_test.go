@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"io"
+	"myapp/storage"
+	"testing"
+)
+
+// countingStorage counts the writes reaching the storage it wraps.
+type countingStorage struct {
+	*storage.Memory
+	puts int
+}
+
+func (c *countingStorage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	c.puts++
+	return c.Memory.Put(ctx, key, body, size, contentType)
+}
+
+func TestAttachmentPut(t *testing.T) {
+	ctx := context.Background()
+	key := attachmentKey("ab0123456789")
+
+	tests := []struct {
+		name    string
+		prepare func(store *countingStorage)
+		puts    int
+	}{
+		{
+			name:    "new file is stored",
+			prepare: func(store *countingStorage) {},
+			puts:    1,
+		},
+		{
+			name: "stored file is reused",
+			prepare: func(store *countingStorage) {
+				attachmentPut(ctx, store, key, []byte("data"), "text/plain")
+			},
+			puts: 1,
+		},
+		{
+			name: "swept file is stored again",
+			prepare: func(store *countingStorage) {
+				attachmentPut(ctx, store, key, []byte("data"), "text/plain")
+				store.Delete(ctx, key)
+			},
+			puts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &countingStorage{Memory: storage.NewMemory()}
+			tt.prepare(store)
+
+			if err := attachmentPut(ctx, store, key, []byte("data"), "text/plain"); err != nil {
+				t.Fatal(err)
+			}
+
+			if store.puts != tt.puts {
+				t.Fatalf("puts = %d, want %d", store.puts, tt.puts)
+			}
+
+			body, err := store.Get(ctx, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer body.Close()
+
+			data, _ := io.ReadAll(body)
+			if string(data) != "data" {
+				t.Fatalf("stored %q, want %q", data, "data")
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"log"
+	"mime"
+	"myapp/config"
+	"myapp/middleware"
+	"myapp/model"
+	"myapp/storage"
+	"myapp/tools"
+	"net/http"
+	"time"
+
+	"github.com/disintegration/imaging"
+	"gorm.io/gorm"
+
+	_ "image/gif"
+	_ "image/jpeg"
+
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	avatarMinDimension = 32
+	avatarQueueSize    = 100
+
+	// avatarMaxDimension keeps a decoded original under 64 MiB.
+	avatarMaxDimension = 4096
+)
+
+var avatarAllowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+type avatarJob struct {
+	userID int
+	hash   string
+}
+
+var avatarJobs = make(chan avatarJob, avatarQueueSize)
+
+// StartAvatarWorkers starts the goroutines that turn uploaded originals into
+// the configured avatar sizes. Uploads left unprocessed by a previous run are
+// queued again in the background, startup does not wait for the queue.
+func StartAvatarWorkers(workers int) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range avatarJobs {
+				avatarRun(job)
+			}
+		}()
+	}
+
+	go func() {
+		var users []*model.User
+		if err := GetService().DB.Model(&users).Where("avatar_status = ? AND avatar_pending_hash IS NOT NULL", model.AvatarStatusProcessing).Find(&users).Error; err != nil {
+			log.Println("avatar workers:", err)
+			return
+		}
+
+		for _, user := range users {
+			avatarJobs <- avatarJob{userID: user.ID, hash: *user.AvatarPendingHash}
+		}
+	}()
+}
+
+// avatarRun processes one job, a panic only costs that job and not the
+// worker.
+func avatarRun(job avatarJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("avatar of user %d: %v", job.userID, r)
+		}
+	}()
+
+	avatarProcess(job)
+}
+
+// UserAvatarUpload validates an image and stores it as the original of the
+// logged in user's next avatar. The resizing runs in the background; the user
+// stays in the processing state until it is done.
+func (s *Service) UserAvatarUpload(ctx context.Context, data []byte) (*model.User, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		user    model.User
+	)
+
+	if int64(len(data)) > config.GetAvatarMaxSize() {
+		panic(tools.NewCustomError(413, fmt.Sprintf("Avatar is larger than %d bytes", config.GetAvatarMaxSize())))
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if !avatarAllowedTypes[contentType] {
+		panic(tools.NewCustomError(415, "Avatar must be a JPEG, PNG, GIF or WebP image"))
+	}
+
+	// Check the dimensions before anything decodes the pixels.
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		panic(tools.NewCustomError(400, "Invalid image"))
+	}
+
+	if !avatarCheckDimensions(imageConfig) {
+		panic(tools.NewCustomError(400, fmt.Sprintf("Avatar must be between %d and %d pixels wide and high", avatarMinDimension, avatarMaxDimension)))
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	if err := storage.GetStorage().Put(ctx, avatarOriginalKey(getUser.ID, hash), bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		panic(err)
+	}
+
+	if err := s.DB.Model(&user).Where("id = ?", getUser.ID).Updates(map[string]interface{}{
+		"avatar_status":       model.AvatarStatusProcessing,
+		"avatar_pending_hash": hash,
+		"updated_at":          time.Now().UTC(),
+	}).Error; err != nil {
+		panic(err)
+	}
+
+	job := avatarJob{userID: getUser.ID, hash: hash}
+	s.AfterCommit(func() {
+		go func() {
+			avatarJobs <- job
+		}()
+	})
+
+	return s.UserGetByID(ctx, getUser.ID)
+}
+
+// UserAvatarDelete drops the uploaded avatar, the identicon is served again.
+// Its stored images are deleted after commit.
+func (s *Service) UserAvatarDelete(ctx context.Context) (*model.User, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		user    model.User
+	)
+
+	current, _ := s.UserGetByID(ctx, getUser.ID)
+
+	if err := s.DB.Model(&user).Where("id = ?", getUser.ID).Updates(map[string]interface{}{
+		"avatar_status":       model.AvatarStatusNone,
+		"avatar_hash":         nil,
+		"avatar_pending_hash": nil,
+		"updated_at":          time.Now().UTC(),
+	}).Error; err != nil {
+		panic(err)
+	}
+
+	if current.AvatarHash != nil {
+		s.avatarRelease(*current.AvatarHash)
+	}
+
+	if current.AvatarPendingHash != nil {
+		key := avatarOriginalKey(getUser.ID, *current.AvatarPendingHash)
+		s.AfterCommit(func() {
+			storage.GetStorage().Delete(context.Background(), key)
+		})
+	}
+
+	return s.UserGetByID(ctx, getUser.ID)
+}
+
+// avatarRelease deletes the resized images of hash once no user has it as
+// avatar or is having it processed, images are shared by users who uploaded
+// the same file.
+func (s *Service) avatarRelease(hash string) {
+	var (
+		user  model.User
+		count int64
+	)
+
+	if err := s.DB.Model(&user).Where("avatar_hash = ? OR avatar_pending_hash = ?", hash, hash).Count(&count).Error; err != nil {
+		panic(err)
+	}
+
+	if count > 0 {
+		return
+	}
+
+	s.AfterCommit(func() {
+		for _, size := range config.GetAvatarSizes() {
+			if err := storage.GetStorage().Delete(context.Background(), avatarKey(hash, size)); err != nil {
+				log.Printf("avatar %s: %v", hash, err)
+			}
+		}
+	})
+}
+
+// avatarCheckDimensions bounds an image before its pixels are decoded.
+func avatarCheckDimensions(imageConfig image.Config) bool {
+	return imageConfig.Width >= avatarMinDimension && imageConfig.Height >= avatarMinDimension &&
+		imageConfig.Width <= avatarMaxDimension && imageConfig.Height <= avatarMaxDimension
+}
+
+// UserAvatarGet opens the avatar of a user at the configured size closest to
+// size, falling back to an identicon when no avatar was processed.
+func (s *Service) UserAvatarGet(ctx context.Context, userID int, size int) (*model.AvatarImage, error) {
+	var (
+		user model.User
+	)
+
+	if err := s.DB.Model(&user).Scopes(tools.IsDeletedAtNull).Select("id", "avatar_hash").Where("id = ?", userID).First(&user).Error; err == gorm.ErrRecordNotFound {
+		panic(tools.NewCustomError(404, "User not found"))
+	} else if err != nil {
+		panic(err)
+	}
+
+	size = avatarPickSize(size)
+
+	if user.AvatarHash != nil {
+		body, err := storage.GetStorage().Get(ctx, avatarKey(*user.AvatarHash, size))
+		if err == nil {
+			defer body.Close()
+
+			data, err := io.ReadAll(body)
+			if err != nil {
+				panic(err)
+			}
+
+			return &model.AvatarImage{
+				Data:        data,
+				ContentType: "image/png",
+				ETag:        fmt.Sprintf(`"%s-%d"`, (*user.AvatarHash)[:16], size),
+			}, nil
+		} else if err != storage.ErrNotFound {
+			panic(err)
+		}
+	}
+
+	return &model.AvatarImage{
+		Data:        tools.Identicon(fmt.Sprintf("user:%d", user.ID), size),
+		ContentType: "image/png",
+		ETag:        fmt.Sprintf(`"identicon-%d-%d"`, user.ID, size),
+	}, nil
+}
+
+// avatarProcess decodes an original honoring its EXIF orientation, crops it
+// to a centered square and writes every configured size as PNG. Re-encoding
+// leaves EXIF, GPS and any other metadata behind, and the original is deleted
+// once done, as are the images of the avatar it replaces. Images made for an
+// upload that failed, or was replaced or deleted meanwhile, are not kept
+// either.
+func avatarProcess(job avatarJob) {
+	var (
+		ctx      = context.Background()
+		user     model.User
+		previous model.User
+		s        = GetService()
+	)
+
+	defer storage.GetStorage().Delete(ctx, avatarOriginalKey(job.userID, job.hash))
+
+	err := func() (err error) {
+		// A decoder choking on a crafted file fails the upload only.
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("decode: %v", r)
+			}
+		}()
+
+		body, err := storage.GetStorage().Get(ctx, avatarOriginalKey(job.userID, job.hash))
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+
+		data, err := io.ReadAll(io.LimitReader(body, config.GetAvatarMaxSize()+1))
+		if err != nil {
+			return err
+		}
+
+		// Originals queued before the limits changed are checked again.
+		imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+
+		if !avatarCheckDimensions(imageConfig) {
+			return fmt.Errorf("image of %dx%d pixels is out of bounds", imageConfig.Width, imageConfig.Height)
+		}
+
+		img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+		if err != nil {
+			return err
+		}
+
+		for _, size := range config.GetAvatarSizes() {
+			var buf bytes.Buffer
+
+			resized := imaging.Fill(img, size, size, imaging.Center, imaging.Lanczos)
+			if err := png.Encode(&buf, resized); err != nil {
+				return err
+			}
+
+			if err := storage.GetStorage().Put(ctx, avatarKey(job.hash, size), &buf, int64(buf.Len()), "image/png"); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}()
+
+	updates := map[string]interface{}{
+		"avatar_status":       model.AvatarStatusReady,
+		"avatar_hash":         job.hash,
+		"avatar_pending_hash": nil,
+	}
+
+	if err != nil {
+		log.Printf("avatar of user %d: %v", job.userID, err)
+		updates = map[string]interface{}{
+			"avatar_status":       model.AvatarStatusFailed,
+			"avatar_pending_hash": nil,
+		}
+	}
+
+	if err := s.DB.Model(&previous).Where("id = ?", job.userID).Take(&previous).Error; err != nil {
+		log.Printf("avatar of user %d: %v", job.userID, err)
+		return
+	}
+
+	// A newer upload may have replaced this one while it was processed.
+	result := s.DB.Model(&user).Where("id = ? AND avatar_pending_hash = ?", job.userID, job.hash).Updates(updates)
+	if result.Error != nil {
+		log.Printf("avatar of user %d: %v", job.userID, result.Error)
+		return
+	}
+
+	if err != nil || result.RowsAffected == 0 {
+		s.avatarRelease(job.hash)
+	} else if previous.AvatarHash != nil && *previous.AvatarHash != job.hash {
+		s.avatarRelease(*previous.AvatarHash)
+	}
+}
+
+func avatarPickSize(size int) int {
+	sizes := config.GetAvatarSizes()
+
+	for _, available := range sizes {
+		if available >= size {
+			return available
+		}
+	}
+
+	return sizes[len(sizes)-1]
+}
+
+func avatarOriginalKey(userID int, hash string) string {
+	return fmt.Sprintf("avatars/originals/%d-%s", userID, hash)
+}
+
+func avatarKey(hash string, size int) string {
+	return fmt.Sprintf("avatars/%s/%d.png", hash, size)
+}
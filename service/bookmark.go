@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"myapp/middleware"
+	"myapp/model"
+	"myapp/tools"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const bookmarkFolderMaxLength = 100
+
+func (s *Service) BookmarkFolderCreate(ctx context.Context, input model.NewBookmarkFolder) (*model.BookmarkFolder, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		count   int64
+	)
+
+	folder := model.BookmarkFolder{
+		UserID:    getUser.ID,
+		Name:      strings.TrimSpace(input.Name),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if folder.Name == "" || utf8.RuneCountInString(folder.Name) > bookmarkFolderMaxLength {
+		panic(tools.NewCustomError(400, "Invalid bookmark folder name"))
+	}
+
+	if err := s.DB.Model(&folder).Where("user_id = ? AND name = ?", folder.UserID, folder.Name).Count(&count).Error; err != nil {
+		panic(err)
+	}
+
+	if count > 0 {
+		panic(tools.NewCustomError(400, "Bookmark folder already exists"))
+	}
+
+	if err := s.DB.Model(&folder).Create(&folder).Error; err != nil {
+		panic(err)
+	}
+
+	return &folder, nil
+}
+
+func (s *Service) BookmarkFolderGetAll(ctx context.Context) ([]*model.BookmarkFolder, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		folders []*model.BookmarkFolder
+	)
+
+	if err := s.DB.Model(&folders).
+		Select("bookmark_folder.*, (SELECT COUNT(*) FROM bookmark WHERE bookmark.folder_id = bookmark_folder.id) AS bookmark_count").
+		Where("user_id = ?", getUser.ID).
+		Order("name").
+		Find(&folders).Error; err != nil {
+		panic(err)
+	}
+
+	return folders, nil
+}
+
+// BookmarkFolderDeleteByID removes a folder, its bookmarks are kept outside
+// of any folder.
+func (s *Service) BookmarkFolderDeleteByID(ctx context.Context, id int) (string, error) {
+	var (
+		folder model.BookmarkFolder
+	)
+
+	s.bookmarkFolderGetOwned(ctx, id)
+
+	if err := s.DB.Model(&model.Bookmark{}).Where("folder_id = ?", id).Update("folder_id", nil).Error; err != nil {
+		panic(err)
+	}
+
+	if err := s.DB.Where("id = ?", id).Delete(&folder).Error; err != nil {
+		panic(err)
+	}
+
+	return "Success", nil
+}
+
+// BookmarkPut saves a post for the logged in user, or moves the bookmark to
+// input.FolderID when the post is already saved.
+func (s *Service) BookmarkPut(ctx context.Context, input model.NewBookmark) (*model.Bookmark, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+	)
+
+	s.PostGetByID(ctx, input.PostID)
+
+	if input.FolderID != nil {
+		s.bookmarkFolderGetOwned(ctx, *input.FolderID)
+	}
+
+	bookmark := model.Bookmark{
+		UserID:    getUser.ID,
+		PostID:    input.PostID,
+		FolderID:  input.FolderID,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := s.DB.Model(&bookmark).Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"folder_id"}),
+	}).Create(&bookmark).Error; err != nil {
+		panic(err)
+	}
+
+	if err := s.DB.Model(&bookmark).Where("user_id = ? AND post_id = ?", getUser.ID, input.PostID).First(&bookmark).Error; err != nil {
+		panic(err)
+	}
+
+	return &bookmark, nil
+}
+
+// BookmarkDelete removes a saved post. Removing a bookmark that does not
+// exist is not an error.
+func (s *Service) BookmarkDelete(ctx context.Context, postID int) (string, error) {
+	var (
+		getUser  = middleware.AuthContext(ctx)
+		bookmark model.Bookmark
+	)
+
+	if err := s.DB.Where("user_id = ? AND post_id = ?", getUser.ID, postID).Delete(&bookmark).Error; err != nil {
+		panic(err)
+	}
+
+	return "Success", nil
+}
+
+// BookmarkGetAll lists the posts the logged in user saved, most recently
+// saved first. Posts the user can no longer read are left out.
+func (s *Service) BookmarkGetAll(ctx context.Context, filter model.BookmarkFilter) (*model.PostPage, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		rows    []*model.Bookmark
+	)
+
+	if filter.Limit <= 0 {
+		filter.Limit = postDefaultLimit
+	} else if filter.Limit > postMaxLimit {
+		filter.Limit = postMaxLimit
+	}
+
+	query := s.DB.Model(&rows).
+		Joins("JOIN post ON post.id = bookmark.post_id AND post.deleted_at IS NULL").
+		Scopes(postReadableBy(ctx)).
+		Where("bookmark.user_id = ?", getUser.ID)
+
+	if filter.FolderID != nil {
+		s.bookmarkFolderGetOwned(ctx, *filter.FolderID)
+		query = query.Where("bookmark.folder_id = ?", *filter.FolderID)
+	} else if filter.Unfiled {
+		query = query.Where("bookmark.folder_id IS NULL")
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := tools.DecodeCursor(filter.Cursor)
+		if err != nil {
+			panic(err)
+		}
+
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil || cursor.Sort != "bookmarked_at:desc" {
+			panic(tools.NewCustomError(400, "Invalid cursor"))
+		}
+
+		query = query.Where("(bookmark.created_at < ? OR (bookmark.created_at = ? AND bookmark.post_id < ?))", createdAt, createdAt, cursor.ID)
+	}
+
+	if err := query.Select("bookmark.*").Order("bookmark.created_at desc").Order("bookmark.post_id desc").Limit(filter.Limit + 1).Find(&rows).Error; err != nil {
+		panic(err)
+	}
+
+	page := model.PostPage{}
+
+	if len(rows) > filter.Limit {
+		rows = rows[:filter.Limit]
+		page.HasMore = true
+
+		last := rows[len(rows)-1]
+		page.NextCursor = tools.EncodeCursor(tools.Cursor{
+			Sort:  "bookmarked_at:desc",
+			Value: last.CreatedAt.UTC().Format(time.RFC3339Nano),
+			ID:    last.PostID,
+		})
+	}
+
+	ids := make([]int, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.PostID)
+	}
+
+	page.Posts = s.postGetByIDs(ctx, ids)
+
+	return &page, nil
+}
+
+// postBookmarkedBy tells which of postIDs the logged in user has saved.
+func (s *Service) postBookmarkedBy(ctx context.Context, postIDs []int) map[int]bool {
+	var (
+		getUser   = middleware.AuthContext(ctx)
+		bookmarks []*model.Bookmark
+		saved     = map[int]bool{}
+	)
+
+	if getUser == nil || len(postIDs) == 0 {
+		return saved
+	}
+
+	if err := s.DB.Model(&bookmarks).Where("user_id = ? AND post_id IN ?", getUser.ID, postIDs).Find(&bookmarks).Error; err != nil {
+		panic(err)
+	}
+
+	for _, bookmark := range bookmarks {
+		saved[bookmark.PostID] = true
+	}
+
+	return saved
+}
+
+func (s *Service) bookmarkFolderGetOwned(ctx context.Context, id int) *model.BookmarkFolder {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		folder  model.BookmarkFolder
+	)
+
+	if err := s.DB.Model(&folder).Where("id = ? AND user_id = ?", id, getUser.ID).First(&folder).Error; err == gorm.ErrRecordNotFound {
+		panic(tools.NewCustomError(404, "Bookmark folder not found"))
+	} else if err != nil {
+		panic(err)
+	}
+
+	return &folder
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"myapp/model"
+	"myapp/tools"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// categoryNameMaxLength is the size of the name and slug columns.
+const categoryNameMaxLength = 64
+
+func (s *Service) CategoryCreate(ctx context.Context, input model.NewCategory) (*model.Category, error) {
+	var (
+		count int64
+	)
+
+	category := model.Category{
+		Name:      strings.TrimSpace(input.Name),
+		Slug:      tools.Slugify(input.Name),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if category.Slug == "" || len(category.Slug) > categoryNameMaxLength || utf8.RuneCountInString(category.Name) > categoryNameMaxLength {
+		panic(tools.NewCustomError(400, "Invalid category name"))
+	}
+
+	if err := s.DB.Model(&category).Where("slug = ?", category.Slug).Count(&count).Error; err != nil {
+		panic(err)
+	}
+
+	if count > 0 {
+		panic(tools.NewCustomError(400, "Category already exists"))
+	}
+
+	if err := s.DB.Model(&category).Create(&category).Error; err != nil {
+		panic(err)
+	}
+
+	return &category, nil
+}
+
+func (s *Service) CategoryGetAll(ctx context.Context) ([]*model.CategoryCount, error) {
+	var (
+		categories []*model.CategoryCount
+	)
+
+	if err := s.DB.Table("category").
+		Select("category.id, category.name, category.slug, COUNT(post.id) AS post_count").
+		Joins("LEFT JOIN post_category ON post_category.category_id = category.id").
+		Joins("LEFT JOIN post ON post.id = post_category.post_id AND post.deleted_at IS NULL AND post.status = ? AND post.visibility = ?", model.PostStatusPublished, model.PostVisibilityPublic).
+		Group("category.id").
+		Order("category.name").
+		Scan(&categories).Error; err != nil {
+		panic(err)
+	}
+
+	return categories, nil
+}
+
+// PostCategoriesSet replaces the categories of a post. Unlike tags,
+// categories must already exist.
+func (s *Service) PostCategoriesSet(ctx context.Context, postID int, slugs []string) error {
+	var (
+		categories []*model.Category
+		seen       = map[string]bool{}
+		unique     []string
+	)
+
+	for _, slug := range slugs {
+		slug = tools.Slugify(slug)
+		if !seen[slug] {
+			seen[slug] = true
+			unique = append(unique, slug)
+		}
+	}
+
+	if len(unique) > 0 {
+		if err := s.DB.Model(&categories).Where("slug IN ?", unique).Find(&categories).Error; err != nil {
+			panic(err)
+		}
+
+		if len(categories) != len(unique) {
+			panic(tools.NewCustomError(400, "Unknown category"))
+		}
+	}
+
+	if err := s.DB.Exec("DELETE FROM post_category WHERE post_id = ?", postID).Error; err != nil {
+		panic(err)
+	}
+
+	for _, category := range categories {
+		if err := s.DB.Exec("INSERT INTO post_category (post_id, category_id) VALUES (?, ?)", postID, category.ID).Error; err != nil {
+			panic(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"myapp/middleware"
+	"myapp/model"
+	"myapp/tools"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	commentDefaultLimit = 50
+	commentMaxLimit     = 100
+	commentMaxLength    = 10000
+
+	// commentTreeMaxReplies caps the replies loaded under one page of top
+	// level comments, deeper ones are left out of the tree and can still be
+	// read from the flat list.
+	commentTreeMaxReplies = 1000
+)
+
+func (s *Service) CommentCreate(ctx context.Context, input model.NewComment) (*model.Comment, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+	)
+
+	body := commentCheckBody(input.Body)
+
+	// Only posts the user can read can be commented on.
+	s.PostGetByID(ctx, input.PostID)
+
+	if input.ParentID != nil {
+		parent, _ := s.CommentGetByID(ctx, *input.ParentID)
+		if parent.PostID != input.PostID {
+			panic(tools.NewCustomError(400, "Parent comment belongs to another post"))
+		}
+	}
+
+	comment := model.Comment{
+		PostID:    input.PostID,
+		UserID:    getUser.ID,
+		ParentID:  input.ParentID,
+		Body:      body,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := s.DB.Model(&comment).Omit("updated_at").Create(&comment).Error; err != nil {
+		panic(err)
+	}
+
+	return s.CommentGetByID(ctx, comment.ID)
+}
+
+func (s *Service) CommentUpdate(ctx context.Context, input model.UpdateComment) (*model.Comment, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		comment model.Comment
+	)
+
+	body := commentCheckBody(input.Body)
+
+	current, _ := s.CommentGetByID(ctx, input.ID)
+	if current.UserID != getUser.ID {
+		panic(tools.NewCustomError(403, "You are not the author of this comment"))
+	}
+
+	// Comments on a post the user can no longer read are frozen.
+	s.PostGetByID(ctx, current.PostID)
+
+	if err := s.DB.Model(&comment).Scopes(tools.IsDeletedAtNull).Where("id = ?", input.ID).Updates(map[string]interface{}{
+		"body":       body,
+		"updated_at": time.Now().UTC(),
+	}).Error; err != nil {
+		panic(err)
+	}
+
+	return s.CommentGetByID(ctx, input.ID)
+}
+
+// CommentDeleteByID soft deletes a comment. Besides its author, the author of
+// the post can delete any comment on it.
+func (s *Service) CommentDeleteByID(ctx context.Context, id int) (string, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		comment model.Comment
+	)
+
+	current, _ := s.CommentGetByID(ctx, id)
+	if current.UserID != getUser.ID {
+		post, _ := s.PostGetByID(ctx, current.PostID)
+		if post.UserID != getUser.ID {
+			panic(tools.NewCustomError(403, "You are not allowed to delete this comment"))
+		}
+	}
+
+	if err := s.DB.Model(&comment).Scopes(tools.IsDeletedAtNull).Where("id = ?", id).Omit("updated_at").Update("deleted_at", time.Now().UTC()).Error; err != nil {
+		panic(err)
+	}
+
+	return "Success", nil
+}
+
+func (s *Service) CommentGetByID(ctx context.Context, id int) (*model.Comment, error) {
+	var (
+		comment model.Comment
+	)
+
+	if err := s.DB.Model(&comment).Scopes(tools.IsDeletedAtNull).Preload("Author").Where("id = ?", id).First(&comment).Error; err == gorm.ErrRecordNotFound {
+		panic(tools.NewCustomError(404, "comment not found"))
+	} else if err != nil {
+		panic(err)
+	}
+
+	return &comment, nil
+}
+
+// CommentGetAllByPostID lists the comments of a post oldest first, flat and
+// paginated.
+func (s *Service) CommentGetAllByPostID(ctx context.Context, filter model.CommentFilter) (*model.CommentPage, error) {
+	var (
+		comments []*model.Comment
+	)
+
+	s.PostGetByID(ctx, filter.PostID)
+
+	if filter.Limit <= 0 {
+		filter.Limit = commentDefaultLimit
+	} else if filter.Limit > commentMaxLimit {
+		filter.Limit = commentMaxLimit
+	}
+
+	query := s.DB.Model(&comments).Scopes(tools.IsDeletedAtNull).Preload("Author").Where("post_id = ?", filter.PostID)
+
+	if filter.Cursor != "" {
+		cursor, err := tools.DecodeCursor(filter.Cursor)
+		if err != nil {
+			panic(err)
+		}
+
+		query = query.Where("id > ?", cursor.ID)
+	}
+
+	if err := query.Order("id").Limit(filter.Limit + 1).Find(&comments).Error; err != nil {
+		panic(err)
+	}
+
+	page := model.CommentPage{
+		Comments: comments,
+	}
+
+	if len(comments) > filter.Limit {
+		page.Comments = comments[:filter.Limit]
+		page.HasMore = true
+		page.NextCursor = tools.EncodeCursor(tools.Cursor{
+			Sort: "id",
+			ID:   page.Comments[len(page.Comments)-1].ID,
+		})
+	}
+
+	return &page, nil
+}
+
+// CommentGetTreeByPostID returns a page of the top level comments of a post,
+// oldest first, with their replies nested. Deleted comments that still have
+// replies are kept, with their body blanked, so the thread stays intact.
+func (s *Service) CommentGetTreeByPostID(ctx context.Context, filter model.CommentFilter) (*model.CommentPage, error) {
+	var (
+		roots   []*model.Comment
+		replies int
+	)
+
+	s.PostGetByID(ctx, filter.PostID)
+
+	if filter.Limit <= 0 {
+		filter.Limit = commentDefaultLimit
+	} else if filter.Limit > commentMaxLimit {
+		filter.Limit = commentMaxLimit
+	}
+
+	query := s.DB.Model(&roots).Preload("Author").Where("post_id = ? AND parent_id IS NULL", filter.PostID)
+
+	if filter.Cursor != "" {
+		cursor, err := tools.DecodeCursor(filter.Cursor)
+		if err != nil {
+			panic(err)
+		}
+
+		query = query.Where("id > ?", cursor.ID)
+	}
+
+	if err := query.Order("id").Limit(filter.Limit + 1).Find(&roots).Error; err != nil {
+		panic(err)
+	}
+
+	page := model.CommentPage{}
+
+	if len(roots) > filter.Limit {
+		roots = roots[:filter.Limit]
+		page.HasMore = true
+		page.NextCursor = tools.EncodeCursor(tools.Cursor{
+			Sort: "id",
+			ID:   roots[len(roots)-1].ID,
+		})
+	}
+
+	// Replies are loaded one level at a time, there is no root id to load a
+	// whole thread by.
+	parents := roots
+	for len(parents) > 0 && replies < commentTreeMaxReplies {
+		var (
+			children []*model.Comment
+			byID     = make(map[int]*model.Comment, len(parents))
+			ids      = make([]int, 0, len(parents))
+		)
+
+		for _, parent := range parents {
+			byID[parent.ID] = parent
+			ids = append(ids, parent.ID)
+		}
+
+		if err := s.DB.Model(&children).Preload("Author").Where("post_id = ? AND parent_id IN ?", filter.PostID, ids).Order("id").Limit(commentTreeMaxReplies - replies).Find(&children).Error; err != nil {
+			panic(err)
+		}
+
+		for _, child := range children {
+			parent := byID[*child.ParentID]
+			parent.Replies = append(parent.Replies, child)
+		}
+
+		replies += len(children)
+		parents = children
+	}
+
+	page.Comments = commentPruneDeleted(roots)
+
+	return &page, nil
+}
+
+func commentPruneDeleted(comments []*model.Comment) []*model.Comment {
+	kept := []*model.Comment{}
+
+	for _, comment := range comments {
+		comment.Replies = commentPruneDeleted(comment.Replies)
+
+		if comment.DeletedAt != nil {
+			if len(comment.Replies) == 0 {
+				continue
+			}
+
+			comment.Body = ""
+			comment.Author = nil
+		}
+
+		kept = append(kept, comment)
+	}
+
+	return kept
+}
+
+func commentCheckBody(body string) string {
+	body = strings.TrimSpace(body)
+
+	if body == "" || len(body) > commentMaxLength {
+		panic(tools.NewCustomError(400, "Invalid comment body"))
+	}
+
+	return body
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"myapp/config"
+	"myapp/model"
+	"myapp/tools"
+	"net/url"
+	"strconv"
+)
+
+const feedLimit = 20
+
+var feedExtensions = map[string]string{
+	model.FeedFormatRSS:  "rss",
+	model.FeedFormatAtom: "atom",
+	model.FeedFormatJSON: "json",
+}
+
+// PostFeed lists the latest published posts for the given feed format. Feeds
+// are public documents, so posts are filtered the same way for everyone,
+// logged in or not.
+func (s *Service) PostFeed(ctx context.Context, format string) (*model.Feed, error) {
+	var (
+		posts []*model.Post
+	)
+
+	extension, ok := feedExtensions[format]
+	if !ok {
+		panic(tools.NewCustomError(404, "Unknown feed format"))
+	}
+
+	if err := s.DB.Model(&posts).Scopes(tools.IsDeletedAtNull, postPublic, postPreload).
+		Order("publish_at desc").Order("id desc").
+		Limit(feedLimit).
+		Find(&posts).Error; err != nil {
+		panic(err)
+	}
+
+	base := config.GetPublicURL()
+
+	feed := model.Feed{
+		Title:   config.GetSiteTitle(),
+		HomeURL: base.JoinPath("posts").String(),
+		FeedURL: base.JoinPath("feed." + extension).String(),
+	}
+
+	for _, post := range posts {
+		item := model.FeedItem{
+			ID:          feedPostURL(base, post),
+			URL:         base.JoinPath("posts", "by-slug", post.Slug).String(),
+			Title:       post.Title,
+			ContentHTML: post.ContentHTML,
+			Published:   post.CreatedAt,
+			Updated:     post.CreatedAt,
+		}
+
+		if post.PublishAt != nil {
+			item.Published = *post.PublishAt
+			item.Updated = *post.PublishAt
+		}
+
+		if post.UpdatedAt != nil && post.UpdatedAt.After(item.Updated) {
+			item.Updated = *post.UpdatedAt
+		}
+
+		if post.Author != nil {
+			item.Author = post.Author.Name
+		}
+
+		for _, tag := range post.Tags {
+			item.Tags = append(item.Tags, tag.Name)
+		}
+
+		if feed.Updated == nil || item.Updated.After(*feed.Updated) {
+			updated := item.Updated
+			feed.Updated = &updated
+		}
+
+		feed.Items = append(feed.Items, &item)
+	}
+
+	return &feed, nil
+}
+
+// feedPostURL identifies a post by id, so entries keep their identity when
+// the slug changes.
+func feedPostURL(base *url.URL, post *model.Post) string {
+	u := base.JoinPath("post")
+	u.RawQuery = url.Values{"id": {strconv.Itoa(post.ID)}}.Encode()
+
+	return u.String()
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"myapp/middleware"
+	"myapp/model"
+	"myapp/tools"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	followDefaultLimit = 20
+	followMaxLimit     = 100
+)
+
+type followUserRow struct {
+	ID         int
+	Name       string
+	AvatarHash *string
+	FollowedAt time.Time
+}
+
+// FollowCreate makes the logged in user follow userID. Following someone
+// twice is not an error.
+func (s *Service) FollowCreate(ctx context.Context, userID int) (*model.FollowStats, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+	)
+
+	if userID == getUser.ID {
+		panic(tools.NewCustomError(400, "You cannot follow yourself"))
+	}
+
+	s.followCheckUser(userID)
+
+	follow := model.Follow{
+		FollowerID: getUser.ID,
+		FolloweeID: userID,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	result := s.DB.Model(&follow).Clauses(clause.OnConflict{DoNothing: true}).Create(&follow)
+	if result.Error != nil {
+		panic(result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		s.timelineFollow(getUser.ID, userID)
+	}
+
+	return s.FollowStats(ctx, userID)
+}
+
+func (s *Service) FollowDelete(ctx context.Context, userID int) (*model.FollowStats, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		follow  model.Follow
+	)
+
+	s.followCheckUser(userID)
+
+	result := s.DB.Where("follower_id = ? AND followee_id = ?", getUser.ID, userID).Delete(&follow)
+	if result.Error != nil {
+		panic(result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		s.timelineUnfollow(getUser.ID, userID)
+	}
+
+	return s.FollowStats(ctx, userID)
+}
+
+func (s *Service) FollowStats(ctx context.Context, userID int) (*model.FollowStats, error) {
+	var (
+		getUser   = middleware.AuthContext(ctx)
+		follow    model.Follow
+		followers int64
+		following int64
+	)
+
+	s.followCheckUser(userID)
+
+	if err := s.DB.Model(&follow).Where("followee_id = ?", userID).Count(&followers).Error; err != nil {
+		panic(err)
+	}
+
+	if err := s.DB.Model(&follow).Where("follower_id = ?", userID).Count(&following).Error; err != nil {
+		panic(err)
+	}
+
+	stats := model.FollowStats{
+		UserID:    userID,
+		Followers: int(followers),
+		Following: int(following),
+	}
+
+	if getUser != nil {
+		var count int64
+
+		if err := s.DB.Model(&follow).Where("follower_id = ? AND followee_id = ?", getUser.ID, userID).Count(&count).Error; err != nil {
+			panic(err)
+		}
+
+		stats.FollowedByMe = count > 0
+	}
+
+	return &stats, nil
+}
+
+// FollowGetFollowers lists who follows userID, most recent first.
+func (s *Service) FollowGetFollowers(ctx context.Context, userID int, filter model.FollowFilter) (*model.FollowUserPage, error) {
+	return s.followList(userID, filter, "follow.follower_id", "follow.followee_id")
+}
+
+// FollowGetFollowing lists who userID follows, most recent first.
+func (s *Service) FollowGetFollowing(ctx context.Context, userID int, filter model.FollowFilter) (*model.FollowUserPage, error) {
+	return s.followList(userID, filter, "follow.followee_id", "follow.follower_id")
+}
+
+// followList pages through the follow rows where matchColumn is userID,
+// returning the users in listColumn.
+func (s *Service) followList(userID int, filter model.FollowFilter, listColumn string, matchColumn string) (*model.FollowUserPage, error) {
+	var (
+		rows []*followUserRow
+	)
+
+	s.followCheckUser(userID)
+
+	if filter.Limit <= 0 {
+		filter.Limit = followDefaultLimit
+	} else if filter.Limit > followMaxLimit {
+		filter.Limit = followMaxLimit
+	}
+
+	query := s.DB.Table("follow").
+		Select("user.id, user.name, user.avatar_hash, follow.created_at AS followed_at").
+		Joins("JOIN user ON user.id = "+listColumn+" AND user.deleted_at IS NULL").
+		Where(matchColumn+" = ?", userID)
+
+	if filter.Cursor != "" {
+		cursor, err := tools.DecodeCursor(filter.Cursor)
+		if err != nil {
+			panic(err)
+		}
+
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil || cursor.Sort != "created_at:desc" {
+			panic(tools.NewCustomError(400, "Invalid cursor"))
+		}
+
+		query = query.Where("(follow.created_at < ? OR (follow.created_at = ? AND "+listColumn+" < ?))", createdAt, createdAt, cursor.ID)
+	}
+
+	if err := query.Order("follow.created_at desc").Order(listColumn + " desc").Limit(filter.Limit + 1).Scan(&rows).Error; err != nil {
+		panic(err)
+	}
+
+	page := model.FollowUserPage{
+		Users: []*model.FollowUser{},
+	}
+
+	if len(rows) > filter.Limit {
+		rows = rows[:filter.Limit]
+		page.HasMore = true
+
+		last := rows[len(rows)-1]
+		page.NextCursor = tools.EncodeCursor(tools.Cursor{
+			Sort:  "created_at:desc",
+			Value: last.FollowedAt.UTC().Format(time.RFC3339Nano),
+			ID:    last.ID,
+		})
+	}
+
+	for _, row := range rows {
+		page.Users = append(page.Users, &model.FollowUser{
+			ID:         row.ID,
+			Name:       row.Name,
+			AvatarURL:  tools.UserAvatarURL(row.ID, row.AvatarHash),
+			FollowedAt: row.FollowedAt,
+		})
+	}
+
+	return &page, nil
+}
+
+func (s *Service) followCheckUser(userID int) {
+	var (
+		user model.User
+	)
+
+	if err := s.DB.Model(&user).Scopes(tools.IsDeletedAtNull).Select("id").Where("id = ?", userID).First(&user).Error; err == gorm.ErrRecordNotFound {
+		panic(tools.NewCustomError(404, "User not found"))
+	} else if err != nil {
+		panic(err)
+	}
+}
@@ -9,6 +9,9 @@ import (
 
 type Service struct {
 	DB *gorm.DB
+
+	isTransaction bool
+	afterCommit   []func()
 }
 
 func GetService() *Service {
@@ -22,7 +25,8 @@ func GetService() *Service {
 func GetTransaction() *Service {
 	fmt.Println("begin...")
 	s := Service{
-		DB: config.GetDB().Begin(),
+		DB:            config.GetDB().Begin(),
+		isTransaction: true,
 	}
 
 	return &s
@@ -35,11 +39,17 @@ func (s *Service) Commit() error {
 
 	fmt.Println("commit...")
 
+	for _, fn := range s.afterCommit {
+		fn()
+	}
+	s.afterCommit = nil
+
 	return nil
 }
 
 func (s *Service) Rollback(err ...interface{}) error {
 	s.DB.Rollback()
+	s.afterCommit = nil
 	fmt.Println("rollback...")
 
 	if len(err) > 0 && err[0] != nil {
@@ -56,3 +66,37 @@ func (s *Service) ErrorCheck(err ...interface{}) error {
 
 	return nil
 }
+
+// AfterCommit defers fn until the transaction commits, so side effects outside
+// the database are dropped on rollback. Without a transaction fn runs at once.
+func (s *Service) AfterCommit(fn func()) {
+	if !s.isTransaction {
+		fn()
+		return
+	}
+
+	s.afterCommit = append(s.afterCommit, fn)
+}
+
+// Savepoint runs fn inside a savepoint of the transaction. When fn panics only
+// its own work is rolled back, AfterCommit callbacks it registered included,
+// and the panic is returned as an error so the transaction can carry on.
+func (s *Service) Savepoint(name string, fn func()) (err error) {
+	pending := len(s.afterCommit)
+
+	if err := s.DB.SavePoint(name).Error; err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.DB.RollbackTo(name)
+			s.afterCommit = s.afterCommit[:pending]
+			err = s.ErrorCheck(r)
+		}
+	}()
+
+	fn()
+
+	return nil
+}
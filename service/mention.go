@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"myapp/middleware"
+	"myapp/model"
+	"myapp/tools"
+	"time"
+)
+
+// postMentionsSet resolves the @handles in the content of post and diffs them
+// against the mentions already stored: new ones are recorded, the ones no
+// longer in the content are dropped and the others keep their original time.
+// Unknown handles and the author mentioning themselves are ignored.
+func (s *Service) postMentionsSet(post *model.Post) {
+	var (
+		user     model.User
+		mention  model.PostMention
+		wanted   []int
+		existing []int
+	)
+
+	if handles := tools.ParseMentions(post.Content); len(handles) > 0 {
+		if err := s.DB.Model(&user).Scopes(tools.IsDeletedAtNull).Where("handle IN ? AND id <> ?", handles, post.UserID).Pluck("id", &wanted).Error; err != nil {
+			panic(err)
+		}
+	}
+
+	if err := s.DB.Model(&mention).Where("post_id = ?", post.ID).Pluck("user_id", &existing).Error; err != nil {
+		panic(err)
+	}
+
+	added, removed := mentionDiff(existing, wanted)
+
+	if len(removed) > 0 {
+		if err := s.DB.Exec("DELETE FROM post_mention WHERE post_id = ? AND user_id IN ?", post.ID, removed).Error; err != nil {
+			panic(err)
+		}
+	}
+
+	now := time.Now().UTC()
+	for _, userID := range added {
+		if err := s.DB.Exec("INSERT INTO post_mention (post_id, user_id, created_at) VALUES (?, ?, ?)", post.ID, userID, now).Error; err != nil {
+			panic(err)
+		}
+	}
+}
+
+func mentionDiff(existing []int, wanted []int) ([]int, []int) {
+	var (
+		added   []int
+		removed []int
+		had     = map[int]bool{}
+		keep    = map[int]bool{}
+	)
+
+	for _, id := range existing {
+		had[id] = true
+	}
+
+	for _, id := range wanted {
+		keep[id] = true
+		if !had[id] {
+			added = append(added, id)
+		}
+	}
+
+	for _, id := range existing {
+		if !keep[id] {
+			removed = append(removed, id)
+		}
+	}
+
+	return added, removed
+}
+
+// MentionGetAll lists the posts mentioning the logged in user, most recently
+// mentioned first. Posts the user cannot read, such as drafts or private
+// posts, are left out.
+func (s *Service) MentionGetAll(ctx context.Context, filter model.MentionFilter) (*model.PostPage, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		rows    []*model.PostMention
+	)
+
+	if filter.Limit <= 0 {
+		filter.Limit = postDefaultLimit
+	} else if filter.Limit > postMaxLimit {
+		filter.Limit = postMaxLimit
+	}
+
+	query := s.DB.Model(&rows).
+		Joins("JOIN post ON post.id = post_mention.post_id AND post.deleted_at IS NULL").
+		Scopes(postReadableBy(ctx)).
+		Where("post_mention.user_id = ?", getUser.ID)
+
+	if filter.Cursor != "" {
+		cursor, err := tools.DecodeCursor(filter.Cursor)
+		if err != nil {
+			panic(err)
+		}
+
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil || cursor.Sort != "mentioned_at:desc" {
+			panic(tools.NewCustomError(400, "Invalid cursor"))
+		}
+
+		query = query.Where("(post_mention.created_at < ? OR (post_mention.created_at = ? AND post_mention.post_id < ?))", createdAt, createdAt, cursor.ID)
+	}
+
+	if err := query.Select("post_mention.*").Order("post_mention.created_at desc").Order("post_mention.post_id desc").Limit(filter.Limit + 1).Find(&rows).Error; err != nil {
+		panic(err)
+	}
+
+	page := model.PostPage{}
+
+	if len(rows) > filter.Limit {
+		rows = rows[:filter.Limit]
+		page.HasMore = true
+
+		last := rows[len(rows)-1]
+		page.NextCursor = tools.EncodeCursor(tools.Cursor{
+			Sort:  "mentioned_at:desc",
+			Value: last.CreatedAt.UTC().Format(time.RFC3339Nano),
+			ID:    last.PostID,
+		})
+	}
+
+	ids := make([]int, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.PostID)
+	}
+
+	page.Posts = s.postGetByIDs(ctx, ids)
+
+	return &page, nil
+}
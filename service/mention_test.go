@@ -0,0 +1,37 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMentionDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []int
+		wanted   []int
+		added    []int
+		removed  []int
+	}{
+		{"nothing", nil, nil, nil, nil},
+		{"first mentions", nil, []int{1, 2}, []int{1, 2}, nil},
+		{"all dropped", []int{1, 2}, nil, nil, []int{1, 2}},
+		{"unchanged", []int{1, 2}, []int{2, 1}, nil, nil},
+		{"some added and dropped", []int{1, 2, 3}, []int{2, 4}, []int{4}, []int{1, 3}},
+		{"replaced", []int{1}, []int{2}, []int{2}, []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := mentionDiff(tt.existing, tt.wanted)
+
+			if !reflect.DeepEqual(added, tt.added) {
+				t.Fatalf("added = %v, want %v", added, tt.added)
+			}
+
+			if !reflect.DeepEqual(removed, tt.removed) {
+				t.Fatalf("removed = %v, want %v", removed, tt.removed)
+			}
+		})
+	}
+}
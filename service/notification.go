@@ -0,0 +1,430 @@
+package service
+
+import (
+	"context"
+	"log"
+	"myapp/config"
+	"myapp/middleware"
+	"myapp/model"
+	"myapp/tools"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	notificationDefaultLimit = 50
+	notificationMaxLimit     = 100
+	notificationMessageSize  = 500
+
+	// notificationReplayLimit caps how many missed notifications a
+	// reconnecting stream is sent.
+	notificationReplayLimit = 100
+
+	// notificationPollBatchSize caps how many new notifications the hub
+	// reads per poll, the rest follow on the next one.
+	notificationPollBatchSize = 1000
+
+	// notificationGapTimeout is how long the hub keeps looking for an id it
+	// skipped over, the notification of a transaction that committed after
+	// one with a higher id. Ids of rolled back transactions are given up on
+	// after it. At most notificationGapLimit ids are looked for at a time.
+	notificationGapTimeout = time.Minute
+	notificationGapLimit   = 1000
+)
+
+// NotificationProducer is how features tell a user something happened to
+// their account or content. The notification is stored with the current
+// transaction, the streams of the user pick it up once it commits.
+type NotificationProducer interface {
+	NotificationCreate(ctx context.Context, input model.NewNotification) (*model.Notification, error)
+}
+
+var _ NotificationProducer = (*Service)(nil)
+
+// NotificationEvent is one message on a notification stream: a new
+// notification, or the unread count after notifications were read.
+type NotificationEvent struct {
+	Name         string
+	Notification *model.Notification
+	Unread       *model.NotificationUnread
+}
+
+// notificationHub fans events out to the streams open in this process. It
+// polls the notification table, so notifications created and read through any
+// server instance reach every stream. A subscriber that does not keep up
+// misses events rather than blocking the hub, it catches up through
+// Last-Event-ID when it reconnects.
+//
+// Ids are handed out when a notification is inserted but become visible when
+// its transaction commits, so a lower id can show up after a higher one. The
+// hub remembers the ids it skipped and reads them again on the following
+// polls, every notification is published once.
+//
+// A stream lives as long as the access token it was opened with: once the
+// token expires or is revoked, by logout or by signing its session out, the
+// hub closes the stream.
+type notificationHub struct {
+	mu          sync.Mutex
+	subscribers map[int]map[*notificationSubscriber]struct{}
+
+	// Only used by poll.
+	lastID int
+	gaps   map[int]time.Time
+	unread map[int]int
+}
+
+type notificationSubscriber struct {
+	tokenHash string
+	events    chan *NotificationEvent
+}
+
+var notificationStreams = &notificationHub{
+	subscribers: map[int]map[*notificationSubscriber]struct{}{},
+	gaps:        map[int]time.Time{},
+	unread:      map[int]int{},
+}
+
+func (h *notificationHub) subscribe(userID int, tokenHash string) (chan *NotificationEvent, func()) {
+	sub := &notificationSubscriber{
+		tokenHash: tokenHash,
+		events:    make(chan *NotificationEvent, 16),
+	}
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = map[*notificationSubscriber]struct{}{}
+	}
+	h.subscribers[userID][sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub.events, func() {
+		h.mu.Lock()
+		h.remove(userID, sub)
+		h.mu.Unlock()
+	}
+}
+
+// remove drops sub and closes its events, it must be called with mu held.
+// Removing it twice is a no-op.
+func (h *notificationHub) remove(userID int, sub *notificationSubscriber) {
+	if _, ok := h.subscribers[userID][sub]; !ok {
+		return
+	}
+
+	delete(h.subscribers[userID], sub)
+	if len(h.subscribers[userID]) == 0 {
+		delete(h.subscribers, userID)
+	}
+
+	close(sub.events)
+}
+
+func (h *notificationHub) publish(userID int, event *NotificationEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers[userID] {
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+}
+
+// StartNotificationHub polls for the events of open streams every interval.
+func StartNotificationHub(interval time.Duration) {
+	var (
+		notification model.Notification
+		lastID       []int
+	)
+
+	if err := config.GetDB().Model(&notification).Select("COALESCE(MAX(id), 0)").Pluck("id", &lastID).Error; err != nil {
+		panic(err)
+	}
+
+	notificationStreams.lastID = lastID[0]
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			notificationStreams.poll()
+		}
+	}()
+}
+
+// poll sends the subscribers their new notifications and unread count
+// changes, and closes the streams whose access token is no longer active.
+func (h *notificationHub) poll() {
+	var (
+		db           = config.GetDB()
+		notification model.Notification
+		accessToken  model.AccessTokens
+		users        []int
+		tokens       []string
+		subscribed   = map[int]bool{}
+		checked      = map[*notificationSubscriber]int{}
+		live         = map[string]bool{}
+		gaps         []int
+		now          = time.Now()
+	)
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Println("notification hub:", r)
+		}
+	}()
+
+	h.mu.Lock()
+	for userID, subs := range h.subscribers {
+		users = append(users, userID)
+		subscribed[userID] = true
+		for sub := range subs {
+			checked[sub] = userID
+			tokens = append(tokens, sub.tokenHash)
+		}
+	}
+	h.mu.Unlock()
+
+	for userID := range h.unread {
+		if !subscribed[userID] {
+			delete(h.unread, userID)
+		}
+	}
+
+	for id, skipped := range h.gaps {
+		if now.Sub(skipped) > notificationGapTimeout {
+			delete(h.gaps, id)
+			continue
+		}
+		gaps = append(gaps, id)
+	}
+
+	query := db.Model(&notification).Where("id > ?", h.lastID)
+	if len(gaps) > 0 {
+		query = query.Or("id IN ?", gaps)
+	}
+
+	var notifications []*model.Notification
+	if err := query.Order("id").Limit(notificationPollBatchSize).Find(&notifications).Error; err != nil {
+		panic(err)
+	}
+
+	for _, created := range notifications {
+		if created.ID > h.lastID {
+			for id := h.lastID + 1; id < created.ID && len(h.gaps) < notificationGapLimit; id++ {
+				h.gaps[id] = now
+			}
+			h.lastID = created.ID
+		} else {
+			delete(h.gaps, created.ID)
+		}
+
+		h.publish(created.UserID, &NotificationEvent{
+			Name:         "notification",
+			Notification: created,
+		})
+	}
+
+	if len(users) == 0 {
+		return
+	}
+
+	var active []string
+	if err := db.Model(&accessToken).Scopes(tools.IsAccessTokenActive).Where("access_tokens.token_hash IN ?", tokens).Pluck("access_tokens.token_hash", &active).Error; err != nil {
+		panic(err)
+	}
+
+	for _, tokenHash := range active {
+		live[tokenHash] = true
+	}
+
+	// Streams opened since the snapshot are checked on the next poll.
+	h.mu.Lock()
+	for sub, userID := range checked {
+		if !live[sub.tokenHash] {
+			h.remove(userID, sub)
+		}
+	}
+	h.mu.Unlock()
+
+	var counts []struct {
+		UserID int
+		Unread int
+	}
+
+	if err := db.Model(&notification).Select("user_id, COUNT(*) AS unread").Where("user_id IN ? AND read_at IS NULL", users).Group("user_id").Scan(&counts).Error; err != nil {
+		panic(err)
+	}
+
+	unread := map[int]int{}
+	for _, count := range counts {
+		unread[count.UserID] = count.Unread
+	}
+
+	for _, userID := range users {
+		last, seen := h.unread[userID]
+		if seen && last == unread[userID] {
+			continue
+		}
+
+		h.unread[userID] = unread[userID]
+		h.publish(userID, &NotificationEvent{
+			Name:   "unread",
+			Unread: &model.NotificationUnread{Unread: unread[userID]},
+		})
+	}
+}
+
+func (s *Service) NotificationCreate(ctx context.Context, input model.NewNotification) (*model.Notification, error) {
+	notification := model.Notification{
+		UserID:    input.UserID,
+		Type:      input.Type,
+		Message:   input.Message,
+		ActorID:   input.ActorID,
+		PostID:    input.PostID,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if utf8.RuneCountInString(notification.Message) > notificationMessageSize {
+		notification.Message = string([]rune(notification.Message)[:notificationMessageSize-1]) + "…"
+	}
+
+	if err := s.DB.Model(&notification).Create(&notification).Error; err != nil {
+		panic(err)
+	}
+
+	return &notification, nil
+}
+
+// NotificationGetAll lists the logged in user's notifications, newest first.
+func (s *Service) NotificationGetAll(ctx context.Context, filter model.NotificationFilter) (*model.NotificationPage, error) {
+	var (
+		getUser       = middleware.AuthContext(ctx)
+		notifications []*model.Notification
+	)
+
+	if filter.Limit <= 0 {
+		filter.Limit = notificationDefaultLimit
+	} else if filter.Limit > notificationMaxLimit {
+		filter.Limit = notificationMaxLimit
+	}
+
+	query := s.DB.Model(&notifications).Where("user_id = ?", getUser.ID)
+
+	if filter.Unread {
+		query = query.Where("read_at IS NULL")
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := tools.DecodeCursor(filter.Cursor)
+		if err != nil {
+			panic(err)
+		}
+
+		if cursor.Sort != "id:desc" {
+			panic(tools.NewCustomError(400, "Invalid cursor"))
+		}
+
+		query = query.Where("id < ?", cursor.ID)
+	}
+
+	if err := query.Order("id desc").Limit(filter.Limit + 1).Find(&notifications).Error; err != nil {
+		panic(err)
+	}
+
+	page := model.NotificationPage{
+		Notifications: notifications,
+	}
+
+	if len(notifications) > filter.Limit {
+		page.Notifications = notifications[:filter.Limit]
+		page.HasMore = true
+
+		page.NextCursor = tools.EncodeCursor(tools.Cursor{
+			Sort: "id:desc",
+			ID:   page.Notifications[len(page.Notifications)-1].ID,
+		})
+	}
+
+	return &page, nil
+}
+
+// NotificationGetSince returns the notifications a stream missed after
+// lastID, oldest first.
+func (s *Service) NotificationGetSince(ctx context.Context, lastID int) ([]*model.Notification, error) {
+	var (
+		getUser       = middleware.AuthContext(ctx)
+		notifications []*model.Notification
+	)
+
+	if err := s.DB.Model(&notifications).Where("user_id = ? AND id > ?", getUser.ID, lastID).Order("id").Limit(notificationReplayLimit).Find(&notifications).Error; err != nil {
+		panic(err)
+	}
+
+	return notifications, nil
+}
+
+func (s *Service) NotificationUnread(ctx context.Context) (*model.NotificationUnread, error) {
+	var (
+		getUser      = middleware.AuthContext(ctx)
+		notification model.Notification
+		count        int64
+	)
+
+	if err := s.DB.Model(&notification).Where("user_id = ? AND read_at IS NULL", getUser.ID).Count(&count).Error; err != nil {
+		panic(err)
+	}
+
+	return &model.NotificationUnread{Unread: int(count)}, nil
+}
+
+// NotificationMarkRead marks one of the logged in user's notifications as
+// read. Marking it again keeps the first read time.
+func (s *Service) NotificationMarkRead(ctx context.Context, id int) (*model.NotificationUnread, error) {
+	var (
+		getUser      = middleware.AuthContext(ctx)
+		notification model.Notification
+		count        int64
+	)
+
+	if err := s.DB.Model(&notification).Where("id = ? AND user_id = ?", id, getUser.ID).Count(&count).Error; err != nil {
+		panic(err)
+	}
+
+	if count == 0 {
+		panic(tools.NewCustomError(404, "Notification not found"))
+	}
+
+	if err := s.DB.Model(&notification).Where("id = ? AND read_at IS NULL", id).Update("read_at", time.Now().UTC()).Error; err != nil {
+		panic(err)
+	}
+
+	return s.NotificationUnread(ctx)
+}
+
+func (s *Service) NotificationMarkAllRead(ctx context.Context) (*model.NotificationUnread, error) {
+	var (
+		getUser      = middleware.AuthContext(ctx)
+		notification model.Notification
+	)
+
+	if err := s.DB.Model(&notification).Where("user_id = ? AND read_at IS NULL", getUser.ID).Update("read_at", time.Now().UTC()).Error; err != nil {
+		panic(err)
+	}
+
+	return s.NotificationUnread(ctx)
+}
+
+// NotificationSubscribe opens a stream of the logged in user's notification
+// events, the returned func closes it. The events channel is closed when the
+// access token of the request stops being active.
+func (s *Service) NotificationSubscribe(ctx context.Context) (<-chan *NotificationEvent, func()) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+	)
+
+	return notificationStreams.subscribe(getUser.ID, getUser.TokenHash)
+}
@@ -2,71 +2,281 @@ package service
 
 import (
 	"context"
-	"fmt"
+	"myapp/middleware"
 	"myapp/model"
 	"myapp/tools"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func (s *Service) PostCreate(ctx context.Context, input model.NewPost) (*model.Post, error) {
-	post := model.Post{
-		Title:     input.Title,
-		Content:   input.Content,
-		CreatedAt: time.Now().UTC(),
+	var (
+		getUser = middleware.AuthContext(ctx)
+	)
+
+	postCheckContent(input.Title, input.Content)
+
+	if input.Status == "" {
+		input.Status = model.PostStatusPublished
 	}
 
-	if input.Title == "" || input.Content == "" {
-		err := tools.NewCustomError(400, "Invalid title/content input")
-		panic(err)
+	if input.ContentFormat == "" {
+		input.ContentFormat = model.PostContentFormatPlain
+	}
+
+	if input.Visibility == "" {
+		input.Visibility = model.PostVisibilityPublic
+	}
+
+	status, publishAt := postCheckStatus(input.Status, input.PublishAt, nil)
+
+	post := model.Post{
+		UserID:        getUser.ID,
+		Slug:          s.postUniqueSlug(ctx, input.Title, 0),
+		Title:         input.Title,
+		Content:       input.Content,
+		ContentFormat: input.ContentFormat,
+		ContentHTML:   postRenderContent(input.ContentFormat, input.Content),
+		Status:        status,
+		Visibility:    postCheckVisibility(input.Visibility),
+		PublishAt:     publishAt,
+		CreatedAt:     time.Now().UTC(),
 	}
 
 	if err := s.DB.Model(&post).Omit("updated_at").Create(&post).Error; err != nil {
 		panic(err)
 	}
 
-	return &post, nil
+	s.PostTagsSet(ctx, post.ID, input.Tags)
+	s.PostCategoriesSet(ctx, post.ID, input.Categories)
+	s.postMentionsSet(&post)
+
+	s.postIndex(&post)
+
+	return s.PostGetByID(ctx, post.ID)
 }
 
-func (s *Service) PostUpdate(ctx context.Context, input model.UpdatePost) (*model.Post, error) {
+// PostUpdate rejects the update with 412 when ifMatch is given and does not
+// match the current ETag of the post. A nil ifMatch skips the check.
+func (s *Service) PostUpdate(ctx context.Context, input model.UpdatePost, ifMatch []string) (*model.Post, error) {
 	var (
-		post model.Post
+		getUser = middleware.AuthContext(ctx)
+		post    model.Post
 	)
 
-	if err := s.DB.Model(&post).Scopes(tools.IsDeletedAtNull).Where("id = ?", input.ID).Updates(map[string]interface{}{
-		"title":      input.Title,
-		"content":    input.Content,
-		"updated_at": time.Now().UTC(),
-	}).Error; err != nil {
-		panic(err)
+	s.postLock(input.ID)
+	current, _ := s.PostGetOwnedByID(ctx, input.ID)
+	postCheckPrecondition(current, ifMatch)
+	postCheckContent(input.Title, input.Content)
+
+	if input.Status == "" {
+		input.Status = current.Status
+	}
+
+	if input.ContentFormat == "" {
+		input.ContentFormat = current.ContentFormat
 	}
 
-	return s.PostGetByID(ctx, input.ID)
+	if input.Visibility == "" {
+		input.Visibility = current.Visibility
+	}
+
+	status, publishAt := postCheckStatus(input.Status, input.PublishAt, current)
+
+	s.PostRevisionCreate(ctx, model.PostRevision{
+		PostID:        current.ID,
+		EditorID:      getUser.ID,
+		Title:         current.Title,
+		Content:       current.Content,
+		ContentFormat: current.ContentFormat,
+		CreatedAt:     time.Now().UTC(),
+	})
+
+	result := s.DB.Model(&post).Scopes(tools.IsDeletedAtNull).Where("id = ? AND version = ?", input.ID, current.Version).Updates(map[string]interface{}{
+		"slug":           s.postSlugChange(ctx, current, input.Title),
+		"title":          input.Title,
+		"content":        input.Content,
+		"content_format": input.ContentFormat,
+		"content_html":   postRenderContent(input.ContentFormat, input.Content),
+		"status":         status,
+		"visibility":     postCheckVisibility(input.Visibility),
+		"publish_at":     publishAt,
+		"version":        gorm.Expr("version + 1"),
+		"updated_at":     time.Now().UTC(),
+	})
+	if result.Error != nil {
+		panic(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		postCheckPrecondition(current, []string{})
+	}
+
+	if input.Tags != nil {
+		s.PostTagsSet(ctx, input.ID, input.Tags)
+	}
+
+	if input.Categories != nil {
+		s.PostCategoriesSet(ctx, input.ID, input.Categories)
+	}
+
+	updated, _ := s.PostGetByID(ctx, input.ID)
+	s.postMentionsSet(updated)
+	s.postIndex(updated)
+
+	return updated, nil
 }
 
-func (s *Service) PostDeleteByID(ctx context.Context, id int) (string, error) {
+// PostDeleteByID checks ifMatch the same way as PostUpdate.
+func (s *Service) PostDeleteByID(ctx context.Context, id int, ifMatch []string) (string, error) {
 	var (
 		post model.Post
 	)
 
-	if err := s.DB.Model(&post).Scopes(tools.IsDeletedAtNull).Where("id = ?", id).Omit("updated_at").Update("deleted_at", time.Now().UTC()).Error; err != nil {
+	s.postLock(id)
+	current, _ := s.PostGetOwnedByID(ctx, id)
+	postCheckPrecondition(current, ifMatch)
+
+	if err := s.DB.Model(&post).Scopes(tools.IsDeletedAtNull).Where("id = ?", id).Omit("updated_at").Updates(map[string]interface{}{
+		"version":    gorm.Expr("version + 1"),
+		"deleted_at": time.Now().UTC(),
+	}).Error; err != nil {
 		panic(err)
 	}
 
+	s.postUnindex(id)
+
 	return "Success", nil
 }
 
-func (s *Service) PostGetAll(ctx context.Context) ([]*model.Post, error) {
+const (
+	postDefaultLimit = 20
+	postMaxLimit     = 100
+)
+
+// postSortColumns maps the public sort keys to the column used for keyset
+// pagination. updated_at falls back to created_at for never edited posts.
+var postSortColumns = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "COALESCE(updated_at, created_at)",
+	"title":      "title",
+}
+
+func (s *Service) PostGetAll(ctx context.Context, filter model.PostFilter) (*model.PostPage, error) {
 	var (
 		posts []*model.Post
 	)
 
-	if err := s.DB.Model(&posts).Scopes(tools.IsDeletedAtNull).Find(&posts).Error; err != nil {
+	if filter.Sort == "" {
+		filter.Sort = "created_at"
+	}
+
+	if filter.Order == "" {
+		filter.Order = "desc"
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = postDefaultLimit
+	} else if filter.Limit > postMaxLimit {
+		filter.Limit = postMaxLimit
+	}
+
+	column, ok := postSortColumns[filter.Sort]
+	if !ok {
+		panic(tools.NewCustomError(400, "Invalid sort, expected created_at, updated_at or title"))
+	}
+
+	if filter.Order != "asc" && filter.Order != "desc" {
+		panic(tools.NewCustomError(400, "Invalid order, expected asc or desc"))
+	}
+
+	query := s.DB.Model(&posts).Scopes(tools.IsDeletedAtNull, postVisibleTo(ctx), postPreload)
+
+	if filter.Title != "" {
+		query = query.Where("title LIKE ?", tools.EscapeLike(filter.Title)+"%")
+	}
+
+	if filter.Tag != "" {
+		query = query.Where("id IN (SELECT post_tag.post_id FROM post_tag JOIN tag ON tag.id = post_tag.tag_id WHERE tag.slug = ?)", tools.Slugify(filter.Tag))
+	}
+
+	if filter.Category != "" {
+		query = query.Where("id IN (SELECT post_category.post_id FROM post_category JOIN category ON category.id = post_category.category_id WHERE category.slug = ?)", tools.Slugify(filter.Category))
+	}
+
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", filter.CreatedBefore.UTC())
+	}
+
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at > ?", filter.CreatedAfter.UTC())
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := tools.DecodeCursor(filter.Cursor)
+		if err != nil {
+			panic(err)
+		}
+
+		if cursor.Sort != filter.Sort+":"+filter.Order {
+			panic(tools.NewCustomError(400, "Cursor does not match sort/order"))
+		}
+
+		var value interface{} = cursor.Value
+		if filter.Sort != "title" {
+			t, err := time.Parse(time.RFC3339Nano, cursor.Value)
+			if err != nil {
+				panic(tools.NewCustomError(400, "Invalid cursor"))
+			}
+			value = t
+		}
+
+		op := "<"
+		if filter.Order == "asc" {
+			op = ">"
+		}
+
+		query = query.Where("("+column+" "+op+" ? OR ("+column+" = ? AND id "+op+" ?))", value, value, cursor.ID)
+	}
+
+	if err := query.Order(column + " " + filter.Order).Order("id " + filter.Order).Limit(filter.Limit + 1).Find(&posts).Error; err != nil {
 		panic(err)
 	}
 
-	return posts, nil
+	page := model.PostPage{
+		Posts: posts,
+	}
+
+	if len(posts) > filter.Limit {
+		page.Posts = posts[:filter.Limit]
+		page.HasMore = true
+
+		last := page.Posts[len(page.Posts)-1]
+		page.NextCursor = tools.EncodeCursor(tools.Cursor{
+			Sort:  filter.Sort + ":" + filter.Order,
+			Value: postSortValue(last, filter.Sort),
+			ID:    last.ID,
+		})
+	}
+
+	s.postDecorate(ctx, page.Posts...)
+
+	return &page, nil
+}
+
+func postSortValue(post *model.Post, sort string) string {
+	switch sort {
+	case "title":
+		return post.Title
+	case "updated_at":
+		if post.UpdatedAt != nil {
+			return post.UpdatedAt.UTC().Format(time.RFC3339Nano)
+		}
+	}
+
+	return post.CreatedAt.UTC().Format(time.RFC3339Nano)
 }
 
 func (s *Service) PostGetByID(ctx context.Context, id int) (*model.Post, error) {
@@ -74,11 +284,236 @@ func (s *Service) PostGetByID(ctx context.Context, id int) (*model.Post, error)
 		post model.Post
 	)
 
-	if err := s.DB.Model(&post).Scopes(tools.IsDeletedAtNull).Where("id = ?", id).First(&post).Error; err == gorm.ErrRecordNotFound {
-		panic(fmt.Errorf("post not found"))
+	if err := s.DB.Model(&post).Scopes(tools.IsDeletedAtNull, postReadableBy(ctx), postPreload).Where("id = ?", id).First(&post).Error; err == gorm.ErrRecordNotFound {
+		panic(tools.NewCustomError(404, "post not found"))
 	} else if err != nil {
 		panic(err)
 	}
 
+	s.postDecorate(ctx, &post)
+
 	return &post, nil
 }
+
+// PostGetOwnedByID returns the post only when the logged in user is its author.
+func (s *Service) PostGetOwnedByID(ctx context.Context, id int) (*model.Post, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+	)
+
+	post, _ := s.PostGetByID(ctx, id)
+
+	if getUser == nil || post.UserID != getUser.ID {
+		panic(tools.NewCustomError(403, "You are not the author of this post"))
+	}
+
+	return post, nil
+}
+
+// postIndex refreshes what is derived from a post outside its own row after
+// a write: the search index and, with fan-out-on-write, timelines.
+func (s *Service) postIndex(post *model.Post) {
+	s.searchIndex(post)
+	s.timelineSync(post)
+}
+
+// postUnindex undoes postIndex for a deleted post.
+func (s *Service) postUnindex(id int) {
+	s.searchRemove(id)
+	s.timelineRemove(id)
+}
+
+// postLock takes a row lock on the post before it is read for a write, so
+// the version checked against If-Match cannot change until commit.
+func (s *Service) postLock(id int) {
+	var ids []int
+
+	if err := s.DB.Model(&model.Post{}).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).Pluck("id", &ids).Error; err != nil {
+		panic(err)
+	}
+}
+
+// postCheckPrecondition fails with 412, carrying the current post, when
+// ifMatch does not match its ETag. A nil ifMatch always passes.
+func postCheckPrecondition(current *model.Post, ifMatch []string) {
+	if ifMatch == nil || tools.ETagMatch(ifMatch, tools.PostETag(current.ID, current.Version)) {
+		return
+	}
+
+	panic(&tools.CustomError{
+		Code:    412,
+		Message: "Post has been modified, reload it and try again",
+		Data:    current,
+	})
+}
+
+// postGetByIDs loads and decorates the posts with ids, in the order of ids,
+// for listings that page through another table first. Callers have already
+// checked the posts are readable.
+func (s *Service) postGetByIDs(ctx context.Context, ids []int) []*model.Post {
+	var (
+		posts  []*model.Post
+		sorted = []*model.Post{}
+	)
+
+	if len(ids) == 0 {
+		return sorted
+	}
+
+	if err := s.DB.Model(&posts).Scopes(postPreload).Where("post.id IN ?", ids).Find(&posts).Error; err != nil {
+		panic(err)
+	}
+
+	byID := map[int]*model.Post{}
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+
+	for _, id := range ids {
+		if post, ok := byID[id]; ok {
+			sorted = append(sorted, post)
+		}
+	}
+
+	s.postDecorate(ctx, sorted...)
+
+	return sorted
+}
+
+// postDecorate fills the fields of posts that depend on the request rather
+// than on the post row.
+func (s *Service) postDecorate(ctx context.Context, posts ...*model.Post) {
+	ids := make([]int, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+
+	reactions, _ := s.PostReactionSummaries(ctx, ids)
+	bookmarked := s.postBookmarkedBy(ctx, ids)
+
+	for _, post := range posts {
+		post.Reactions = reactions[post.ID]
+		post.Bookmarked = bookmarked[post.ID]
+	}
+}
+
+// postPreload loads the relations and computed columns returned with a post.
+func postPreload(query *gorm.DB) *gorm.DB {
+	return query.
+		Select("post.*, (SELECT COUNT(*) FROM comment WHERE comment.post_id = post.id AND comment.deleted_at IS NULL) AS comment_count").
+		Preload("Author").
+		Preload("Tags").
+		Preload("Categories")
+}
+
+// postPublic limits a post query to posts anyone may find: published and
+// public. Feeds use it as is.
+func postPublic(query *gorm.DB) *gorm.DB {
+	return query.Where("post.status = ? AND post.visibility = ?", model.PostStatusPublished, model.PostVisibilityPublic)
+}
+
+// postVisibleTo limits a post query to what shows up in listings and search:
+// public posts, plus every post of the logged in user.
+func postVisibleTo(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	var (
+		getUser = middleware.AuthContext(ctx)
+	)
+
+	return func(query *gorm.DB) *gorm.DB {
+		if getUser == nil {
+			return postPublic(query)
+		}
+
+		return query.Where("((post.status = ? AND post.visibility = ?) OR post.user_id = ?)", model.PostStatusPublished, model.PostVisibilityPublic, getUser.ID)
+	}
+}
+
+// postReadableBy limits a post query to what can be opened by id or link,
+// which adds unlisted posts to postVisibleTo.
+func postReadableBy(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		shared  = []string{model.PostVisibilityPublic, model.PostVisibilityUnlisted}
+	)
+
+	return func(query *gorm.DB) *gorm.DB {
+		if getUser == nil {
+			return query.Where("post.status = ? AND post.visibility IN ?", model.PostStatusPublished, shared)
+		}
+
+		return query.Where("((post.status = ? AND post.visibility IN ?) OR post.user_id = ?)", model.PostStatusPublished, shared, getUser.ID)
+	}
+}
+
+func postCheckVisibility(visibility string) string {
+	switch visibility {
+	case model.PostVisibilityPublic, model.PostVisibilityUnlisted, model.PostVisibilityPrivate:
+		return visibility
+	}
+
+	panic(tools.NewCustomError(400, "Invalid visibility, expected public, unlisted or private"))
+}
+
+// postCheckContent holds the title/content rules every write must pass.
+func postCheckContent(title string, content string) {
+	if title == "" || content == "" {
+		panic(tools.NewCustomError(400, "Invalid title/content input"))
+	}
+}
+
+// postRenderContent renders post content to sanitized HTML, which is stored
+// next to the source so reads never render.
+func postRenderContent(format string, content string) string {
+	switch format {
+	case model.PostContentFormatPlain:
+		return tools.PlainToHTML(content)
+	case model.PostContentFormatMarkdown:
+		html, err := tools.MarkdownToHTML(content)
+		if err != nil {
+			panic(err)
+		}
+
+		return html
+	}
+
+	panic(tools.NewCustomError(400, "Invalid content_format, expected plain or markdown"))
+}
+
+// postCheckStatus validates a status change and returns the publish_at that
+// goes with it. current is nil for new posts.
+func postCheckStatus(status string, publishAt *time.Time, current *model.Post) (string, *time.Time) {
+	now := time.Now().UTC()
+
+	switch status {
+	case model.PostStatusPublished:
+		if current != nil && current.Status == model.PostStatusPublished {
+			return status, current.PublishAt
+		}
+
+		return status, &now
+	case model.PostStatusScheduled:
+		if publishAt == nil && current != nil {
+			publishAt = current.PublishAt
+		}
+
+		if publishAt == nil || !publishAt.After(now) {
+			panic(tools.NewCustomError(400, "Scheduled post requires a future publish_at"))
+		}
+
+		utc := publishAt.UTC()
+		return status, &utc
+	case model.PostStatusDraft, model.PostStatusArchived:
+		if publishAt == nil && current != nil {
+			return status, current.PublishAt
+		}
+
+		if publishAt != nil {
+			utc := publishAt.UTC()
+			publishAt = &utc
+		}
+
+		return status, publishAt
+	}
+
+	panic(tools.NewCustomError(400, "Invalid status, expected draft, scheduled, published or archived"))
+}
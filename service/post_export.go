@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"myapp/middleware"
+	"myapp/model"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const postExportBatchSize = 500
+
+// postCSVColumns is the header of CSV exports. Tags and categories are joined
+// with postCSVListSeparator.
+var postCSVColumns = []string{
+	"id", "user_id", "slug", "title", "content", "content_format", "content_html", "status", "visibility",
+	"publish_at", "version", "created_at", "updated_at", "deleted_at", "tags", "categories",
+}
+
+const postCSVListSeparator = "|"
+
+type postRowWriter interface {
+	Write(row *model.PostExportRow) error
+	Flush() error
+}
+
+// PostExport streams posts to w in id order, reading them in batches so the
+// whole table is never held in memory.
+func (s *Service) PostExport(ctx context.Context, filter model.PostExportFilter, w io.Writer) error {
+	var (
+		posts []*model.Post
+	)
+
+	writer := newPostRowWriter(filter.Format, w)
+
+	query := s.DB.Model(&posts).Preload("Tags").Preload("Categories")
+
+	if !filter.IncludeDeleted {
+		query = query.Where("deleted_at IS NULL")
+	}
+
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+
+	if err := query.FindInBatches(&posts, postExportBatchSize, func(tx *gorm.DB, batch int) error {
+		for _, post := range posts {
+			if err := writer.Write(postExportRow(post)); err != nil {
+				return err
+			}
+		}
+
+		return writer.Flush()
+	}).Error; err != nil {
+		panic(err)
+	}
+
+	if err := writer.Flush(); err != nil {
+		panic(err)
+	}
+
+	return nil
+}
+
+func postExportRow(post *model.Post) *model.PostExportRow {
+	createdAt := post.CreatedAt
+
+	row := model.PostExportRow{
+		ID:            post.ID,
+		UserID:        post.UserID,
+		Slug:          post.Slug,
+		Title:         post.Title,
+		Content:       post.Content,
+		ContentFormat: post.ContentFormat,
+		ContentHTML:   post.ContentHTML,
+		Status:        post.Status,
+		Visibility:    post.Visibility,
+		PublishAt:     post.PublishAt,
+		Version:       post.Version,
+		CreatedAt:     &createdAt,
+		UpdatedAt:     post.UpdatedAt,
+		DeletedAt:     post.DeletedAt,
+		Tags:          []string{},
+		Categories:    []string{},
+	}
+
+	for _, tag := range post.Tags {
+		row.Tags = append(row.Tags, tag.Name)
+	}
+
+	for _, category := range post.Categories {
+		row.Categories = append(row.Categories, category.Slug)
+	}
+
+	return &row
+}
+
+// PostExportOwn exports the posts of the logged in user.
+func (s *Service) PostExportOwn(ctx context.Context, filter model.PostExportFilter, w io.Writer) error {
+	var (
+		getUser = middleware.AuthContext(ctx)
+	)
+
+	filter.UserID = getUser.ID
+
+	return s.PostExport(ctx, filter, w)
+}
+
+func newPostRowWriter(format string, w io.Writer) postRowWriter {
+	switch format {
+	case model.PostExportJSONL, "":
+		return &postJSONLWriter{encoder: json.NewEncoder(w)}
+	case model.PostExportCSV:
+		writer := &postCSVWriter{writer: csv.NewWriter(w)}
+		writer.writer.Write(postCSVColumns)
+		return writer
+	}
+
+	panic(postUnknownFormat())
+}
+
+type postJSONLWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *postJSONLWriter) Write(row *model.PostExportRow) error {
+	return w.encoder.Encode(row)
+}
+
+func (w *postJSONLWriter) Flush() error {
+	return nil
+}
+
+type postCSVWriter struct {
+	writer *csv.Writer
+}
+
+func (w *postCSVWriter) Write(row *model.PostExportRow) error {
+	return w.writer.Write([]string{
+		strconv.Itoa(row.ID),
+		strconv.Itoa(row.UserID),
+		row.Slug,
+		row.Title,
+		row.Content,
+		row.ContentFormat,
+		row.ContentHTML,
+		row.Status,
+		row.Visibility,
+		postCSVTime(row.PublishAt),
+		strconv.Itoa(row.Version),
+		postCSVTime(row.CreatedAt),
+		postCSVTime(row.UpdatedAt),
+		postCSVTime(row.DeletedAt),
+		strings.Join(row.Tags, postCSVListSeparator),
+		strings.Join(row.Categories, postCSVListSeparator),
+	})
+}
+
+func (w *postCSVWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func postCSVTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"myapp/middleware"
+	"myapp/model"
+	"myapp/tools"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	postImportBatchSize   = 100
+	postImportMaxLineSize = 16 << 20
+)
+
+type postImportLine struct {
+	line int
+	row  *model.PostExportRow
+	err  error
+}
+
+// PostImport reads posts in the export format and creates them in batches,
+// one transaction per batch. A row that fails validation is rolled back on
+// its own and reported with its line number, the rest of the file still
+// goes in. userID sets the author of every row, 0 keeps each row's user_id.
+func PostImport(ctx context.Context, format string, userID int, r io.Reader) (*model.PostImportResult, error) {
+	var (
+		result = model.PostImportResult{Errors: []*model.PostImportError{}}
+		batch  []*postImportLine
+	)
+
+	next, err := newPostRowReader(format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		line, err := next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return &result, tools.NewCustomError(400, "Cannot read input: "+err.Error())
+		}
+
+		if line.err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, &model.PostImportError{Line: line.line, Message: line.err.Error()})
+			continue
+		}
+
+		batch = append(batch, line)
+		if len(batch) == postImportBatchSize {
+			postImportBatch(ctx, userID, batch, &result)
+			batch = nil
+		}
+	}
+
+	if len(batch) > 0 {
+		postImportBatch(ctx, userID, batch, &result)
+	}
+
+	return &result, nil
+}
+
+// PostImportOwn imports posts with the logged in user as their author.
+func PostImportOwn(ctx context.Context, format string, r io.Reader) (*model.PostImportResult, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+	)
+
+	return PostImport(ctx, format, getUser.ID, r)
+}
+
+func postImportBatch(ctx context.Context, userID int, batch []*postImportLine, result *model.PostImportResult) {
+	var (
+		imported int
+		failed   []*model.PostImportError
+	)
+
+	s := GetTransaction()
+
+	for _, line := range batch {
+		err := s.Savepoint("post_import", func() {
+			s.PostImportRow(ctx, line.row, userID)
+		})
+
+		if err != nil {
+			_, message := tools.APIErrorResponse(err)
+			failed = append(failed, &model.PostImportError{Line: line.line, Message: message})
+			continue
+		}
+
+		imported++
+	}
+
+	if err := s.Commit(); err != nil {
+		s.Rollback()
+
+		imported = 0
+		failed = nil
+		for _, line := range batch {
+			failed = append(failed, &model.PostImportError{Line: line.line, Message: err.Error()})
+		}
+	}
+
+	result.Imported += imported
+	result.Failed += len(failed)
+	result.Errors = append(result.Errors, failed...)
+}
+
+// PostImportRow validates one imported row with the same rules as PostCreate
+// and stores it. Timestamps are kept, the slug is kept unless it is taken.
+func (s *Service) PostImportRow(ctx context.Context, row *model.PostExportRow, userID int) (*model.Post, error) {
+	var (
+		count int64
+	)
+
+	if userID == 0 {
+		userID = row.UserID
+	}
+
+	if userID == 0 {
+		panic(tools.NewCustomError(400, "user_id is required"))
+	}
+
+	if err := s.DB.Model(&model.User{}).Where("id = ?", userID).Count(&count).Error; err != nil {
+		panic(err)
+	}
+
+	if count == 0 {
+		panic(tools.NewCustomError(400, "Unknown user_id "+strconv.Itoa(userID)))
+	}
+
+	postCheckContent(row.Title, row.Content)
+
+	if row.Status == "" {
+		row.Status = model.PostStatusPublished
+	}
+
+	if row.ContentFormat == "" {
+		row.ContentFormat = model.PostContentFormatPlain
+	}
+
+	if row.Visibility == "" {
+		row.Visibility = model.PostVisibilityPublic
+	}
+
+	status, publishAt := postCheckStatus(row.Status, row.PublishAt, nil)
+	if status == model.PostStatusPublished && row.PublishAt != nil {
+		utc := row.PublishAt.UTC()
+		publishAt = &utc
+	}
+
+	slug := row.Slug
+	if slug == "" {
+		slug = row.Title
+	}
+
+	post := model.Post{
+		UserID:        userID,
+		Slug:          s.postUniqueSlug(ctx, slug, 0),
+		Title:         row.Title,
+		Content:       row.Content,
+		ContentFormat: row.ContentFormat,
+		ContentHTML:   postRenderContent(row.ContentFormat, row.Content),
+		Status:        status,
+		Visibility:    postCheckVisibility(row.Visibility),
+		PublishAt:     publishAt,
+		Version:       1,
+		CreatedAt:     time.Now().UTC(),
+		UpdatedAt:     postUTC(row.UpdatedAt),
+		DeletedAt:     postUTC(row.DeletedAt),
+	}
+
+	if row.CreatedAt != nil {
+		post.CreatedAt = row.CreatedAt.UTC()
+	}
+
+	query := s.DB.Model(&post)
+	if post.UpdatedAt == nil {
+		query = query.Omit("updated_at")
+	}
+
+	if err := query.Create(&post).Error; err != nil {
+		panic(err)
+	}
+
+	s.PostTagsSet(ctx, post.ID, row.Tags)
+	s.PostCategoriesSet(ctx, post.ID, row.Categories)
+	s.postMentionsSet(&post)
+
+	if post.DeletedAt == nil {
+		s.postIndex(&post)
+	}
+
+	return &post, nil
+}
+
+func postUTC(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	utc := t.UTC()
+	return &utc
+}
+
+func postUnknownFormat() error {
+	return tools.NewCustomError(400, "Invalid format, expected "+model.PostExportJSONL+" or "+model.PostExportCSV)
+}
+
+// newPostRowReader returns a function yielding one row at a time. Rows that
+// cannot be parsed come back with their error set, io.EOF ends the file and
+// any other error means the input cannot be read any further.
+func newPostRowReader(format string, r io.Reader) (func() (*postImportLine, error), error) {
+	switch format {
+	case model.PostExportJSONL, "":
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), postImportMaxLineSize)
+		number := 0
+
+		return func() (*postImportLine, error) {
+			for scanner.Scan() {
+				number++
+
+				text := strings.TrimSpace(scanner.Text())
+				if text == "" {
+					continue
+				}
+
+				line := postImportLine{line: number, row: &model.PostExportRow{}}
+				line.err = json.Unmarshal([]byte(text), line.row)
+
+				return &line, nil
+			}
+
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+
+			return nil, io.EOF
+		}, nil
+	case model.PostExportCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+
+		header, err := reader.Read()
+		if err != nil {
+			return nil, tools.NewCustomError(400, "Missing CSV header")
+		}
+
+		columns := map[string]int{}
+		for i, name := range header {
+			columns[strings.TrimSpace(name)] = i
+		}
+
+		return func() (*postImportLine, error) {
+			record, err := reader.Read()
+
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return &postImportLine{line: parseErr.Line, err: err}, nil
+			} else if err != nil {
+				return nil, err
+			}
+
+			number, _ := reader.FieldPos(0)
+			line := postImportLine{line: number}
+			line.row, line.err = postCSVRow(columns, record)
+
+			return &line, nil
+		}, nil
+	}
+
+	return nil, postUnknownFormat()
+}
+
+func postCSVRow(columns map[string]int, record []string) (*model.PostExportRow, error) {
+	var (
+		row model.PostExportRow
+		err error
+	)
+
+	field := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+
+		return ""
+	}
+
+	list := func(name string) []string {
+		var items []string
+
+		for _, item := range strings.Split(field(name), postCSVListSeparator) {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+
+		return items
+	}
+
+	timestamp := func(name string) *time.Time {
+		value := field(name)
+		if value == "" || err != nil {
+			return nil
+		}
+
+		t, parseErr := time.Parse(time.RFC3339Nano, value)
+		if parseErr != nil {
+			err = errors.New("invalid " + name + ", expected an RFC3339 timestamp")
+			return nil
+		}
+
+		return &t
+	}
+
+	if value := field("user_id"); value != "" {
+		if row.UserID, err = strconv.Atoi(value); err != nil {
+			return nil, errors.New("invalid user_id")
+		}
+	}
+
+	row.Slug = field("slug")
+	row.Title = field("title")
+	row.Content = field("content")
+	row.ContentFormat = field("content_format")
+	row.Status = field("status")
+	row.Visibility = field("visibility")
+	row.PublishAt = timestamp("publish_at")
+	row.CreatedAt = timestamp("created_at")
+	row.UpdatedAt = timestamp("updated_at")
+	row.DeletedAt = timestamp("deleted_at")
+	row.Tags = list("tags")
+	row.Categories = list("categories")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &row, nil
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"myapp/middleware"
+	"myapp/model"
+	"myapp/tools"
+	"sort"
+	"strings"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"gorm.io/gorm"
+)
+
+// PostPatch applies a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902)
+// to the editable fields of a post. The result must pass the same checks as
+// PostCreate, and only the columns that actually changed are written.
+func (s *Service) PostPatch(ctx context.Context, id int, contentType string, patch []byte, ifMatch []string) (*model.Post, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		post    model.Post
+		doc     model.PostPatchDocument
+	)
+
+	s.postLock(id)
+	current, _ := s.PostGetOwnedByID(ctx, id)
+	postCheckPrecondition(current, ifMatch)
+
+	original, err := json.Marshal(postPatchDocument(current))
+	if err != nil {
+		panic(err)
+	}
+
+	patched := postApplyPatch(contentType, original, patch)
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&doc); err != nil {
+		panic(tools.NewCustomError(422, "Invalid patched post: "+err.Error()))
+	}
+
+	postCheckContent(doc.Title, doc.Content)
+
+	changes := map[string]interface{}{}
+
+	if doc.Title != current.Title {
+		changes["title"] = doc.Title
+		changes["slug"] = s.postSlugChange(ctx, current, doc.Title)
+	}
+
+	if doc.Content != current.Content || doc.ContentFormat != current.ContentFormat {
+		changes["content"] = doc.Content
+		changes["content_format"] = doc.ContentFormat
+		changes["content_html"] = postRenderContent(doc.ContentFormat, doc.Content)
+	}
+
+	// The document carries the current publish_at unless the patch changed
+	// it, so it is taken as is and a null clears it. Only a post that stays
+	// published keeps its original publish time.
+	statusCurrent := current
+	if doc.Status != model.PostStatusPublished {
+		statusCurrent = nil
+	}
+
+	status, publishAt := postCheckStatus(doc.Status, doc.PublishAt, statusCurrent)
+
+	if status != current.Status {
+		changes["status"] = status
+	}
+
+	if doc.Visibility != current.Visibility {
+		changes["visibility"] = postCheckVisibility(doc.Visibility)
+	}
+
+	if !postTimeEqual(publishAt, current.PublishAt) {
+		changes["publish_at"] = publishAt
+	}
+
+	var currentTags []string
+	for _, tag := range current.Tags {
+		currentTags = append(currentTags, tag.Name)
+	}
+	tagsChanged := !postSlugSetEqual(doc.Tags, currentTags)
+
+	var currentCategories []string
+	for _, category := range current.Categories {
+		currentCategories = append(currentCategories, category.Slug)
+	}
+	categoriesChanged := !postSlugSetEqual(doc.Categories, currentCategories)
+
+	if len(changes) == 0 && !tagsChanged && !categoriesChanged {
+		return current, nil
+	}
+
+	_, titleChanged := changes["title"]
+	_, contentChanged := changes["content"]
+
+	if titleChanged || contentChanged {
+		s.PostRevisionCreate(ctx, model.PostRevision{
+			PostID:        current.ID,
+			EditorID:      getUser.ID,
+			Title:         current.Title,
+			Content:       current.Content,
+			ContentFormat: current.ContentFormat,
+			CreatedAt:     time.Now().UTC(),
+		})
+	}
+
+	changes["version"] = gorm.Expr("version + 1")
+	changes["updated_at"] = time.Now().UTC()
+
+	result := s.DB.Model(&post).Scopes(tools.IsDeletedAtNull).Where("id = ? AND version = ?", id, current.Version).Updates(changes)
+	if result.Error != nil {
+		panic(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		postCheckPrecondition(current, []string{})
+	}
+
+	if tagsChanged {
+		s.PostTagsSet(ctx, id, doc.Tags)
+	}
+
+	if categoriesChanged {
+		s.PostCategoriesSet(ctx, id, doc.Categories)
+	}
+
+	updated, _ := s.PostGetByID(ctx, id)
+	if contentChanged {
+		s.postMentionsSet(updated)
+	}
+	s.postIndex(updated)
+
+	return updated, nil
+}
+
+func postPatchDocument(post *model.Post) model.PostPatchDocument {
+	doc := model.PostPatchDocument{
+		Title:         post.Title,
+		Content:       post.Content,
+		ContentFormat: post.ContentFormat,
+		Status:        post.Status,
+		Visibility:    post.Visibility,
+		PublishAt:     post.PublishAt,
+		Tags:          []string{},
+		Categories:    []string{},
+	}
+
+	for _, tag := range post.Tags {
+		doc.Tags = append(doc.Tags, tag.Name)
+	}
+
+	for _, category := range post.Categories {
+		doc.Categories = append(doc.Categories, category.Slug)
+	}
+
+	return doc
+}
+
+func postApplyPatch(contentType string, original []byte, patch []byte) []byte {
+	switch contentType {
+	case model.PostPatchMerge:
+		patched, err := jsonpatch.MergePatch(original, patch)
+		if err != nil {
+			panic(tools.NewCustomError(400, "Invalid merge patch: "+err.Error()))
+		}
+
+		return patched
+	case model.PostPatchJSON:
+		operations, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			panic(tools.NewCustomError(400, "Invalid JSON patch: "+err.Error()))
+		}
+
+		patched, err := operations.Apply(original)
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			panic(tools.NewCustomError(409, err.Error()))
+		} else if err != nil {
+			panic(tools.NewCustomError(422, "Cannot apply JSON patch: "+err.Error()))
+		}
+
+		return patched
+	}
+
+	panic(tools.NewCustomError(415, "Unsupported patch format, expected "+model.PostPatchMerge+" or "+model.PostPatchJSON))
+}
+
+func postTimeEqual(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return a.Equal(*b)
+}
+
+// postSlugSetEqual compares tag or category lists the way they are stored,
+// by slug and ignoring order and duplicates.
+func postSlugSetEqual(a []string, b []string) bool {
+	slugs := func(names []string) []string {
+		seen := map[string]bool{}
+		var out []string
+
+		for _, name := range names {
+			slug := tools.Slugify(strings.TrimSpace(name))
+			if !seen[slug] {
+				seen[slug] = true
+				out = append(out, slug)
+			}
+		}
+
+		sort.Strings(out)
+		return out
+	}
+
+	x, y := slugs(a), slugs(b)
+	if len(x) != len(y) {
+		return false
+	}
+
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"log"
+	"myapp/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const postPublishBatchSize = 100
+
+// StartPostPublisher publishes due scheduled posts every interval. Rows are
+// claimed with SELECT ... FOR UPDATE SKIP LOCKED, so several server instances
+// can run it at once without publishing a post twice.
+func StartPostPublisher(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			runPostPublisher()
+		}
+	}()
+}
+
+func runPostPublisher() {
+	s := GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			log.Println("post publisher:", err)
+		}
+	}()
+
+	count, _ := s.PostPublishDue(context.Background(), time.Now().UTC())
+	s.Commit()
+
+	if count > 0 {
+		log.Printf("post publisher: published %d posts", count)
+	}
+}
+
+func (s *Service) PostPublishDue(ctx context.Context, now time.Time) (int, error) {
+	var (
+		post model.Post
+		ids  []int
+	)
+
+	if err := s.DB.Model(&post).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND publish_at <= ? AND deleted_at IS NULL", model.PostStatusScheduled, now).
+		Order("publish_at").
+		Limit(postPublishBatchSize).
+		Pluck("id", &ids).Error; err != nil {
+		panic(err)
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if err := s.DB.Model(&post).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"status":     model.PostStatusPublished,
+		"version":    gorm.Expr("version + 1"),
+		"updated_at": now,
+	}).Error; err != nil {
+		panic(err)
+	}
+
+	var published []*model.Post
+
+	if err := s.DB.Model(&published).Where("id IN ?", ids).Find(&published).Error; err != nil {
+		panic(err)
+	}
+
+	for _, post := range published {
+		s.postIndex(post)
+	}
+
+	return len(ids), nil
+}
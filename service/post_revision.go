@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"myapp/model"
+	"myapp/tools"
+
+	"gorm.io/gorm"
+)
+
+func (s *Service) PostRevisionCreate(ctx context.Context, revision model.PostRevision) (*model.PostRevision, error) {
+	if err := s.DB.Model(&revision).Create(&revision).Error; err != nil {
+		panic(err)
+	}
+
+	return &revision, nil
+}
+
+func (s *Service) PostRevisionGetAllByPostID(ctx context.Context, postID int) ([]*model.PostRevision, error) {
+	var (
+		revisions []*model.PostRevision
+	)
+
+	s.PostGetOwnedByID(ctx, postID)
+
+	if err := s.DB.Model(&revisions).Where("post_id = ?", postID).Order("id DESC").Find(&revisions).Error; err != nil {
+		panic(err)
+	}
+
+	return revisions, nil
+}
+
+func (s *Service) PostRevisionGetByID(ctx context.Context, postID int, id int) (*model.PostRevision, error) {
+	var (
+		revision model.PostRevision
+	)
+
+	if err := s.DB.Model(&revision).Where("id = ? AND post_id = ?", id, postID).First(&revision).Error; err == gorm.ErrRecordNotFound {
+		panic(tools.NewCustomError(404, "revision not found"))
+	} else if err != nil {
+		panic(err)
+	}
+
+	return &revision, nil
+}
+
+// PostRevisionDiff diffs two revisions of a post. A revision id of 0 stands
+// for the current state of the post.
+func (s *Service) PostRevisionDiff(ctx context.Context, postID int, from int, to int) (*model.PostRevisionDiff, error) {
+	post, _ := s.PostGetOwnedByID(ctx, postID)
+
+	fromName, fromText := s.postRevisionText(ctx, post, from)
+	toName, toText := s.postRevisionText(ctx, post, to)
+
+	return &model.PostRevisionDiff{
+		PostID: postID,
+		From:   from,
+		To:     to,
+		Diff:   tools.UnifiedDiff(fromName, toName, fromText, toText),
+	}, nil
+}
+
+// PostRevisionRestore brings back the title and content of a revision. The
+// state being replaced is kept as a new revision, like any other update.
+func (s *Service) PostRevisionRestore(ctx context.Context, input model.PostRevisionRestore) (*model.Post, error) {
+	s.PostGetOwnedByID(ctx, input.PostID)
+
+	revision, _ := s.PostRevisionGetByID(ctx, input.PostID, input.RevisionID)
+
+	return s.PostUpdate(ctx, model.UpdatePost{
+		ID:            input.PostID,
+		Title:         revision.Title,
+		Content:       revision.Content,
+		ContentFormat: revision.ContentFormat,
+	}, nil)
+}
+
+func (s *Service) postRevisionText(ctx context.Context, post *model.Post, id int) (string, string) {
+	if id == 0 {
+		return "current", postDiffText(post.Title, post.Content)
+	}
+
+	revision, _ := s.PostRevisionGetByID(ctx, post.ID, id)
+
+	return fmt.Sprintf("revision %d", revision.ID), postDiffText(revision.Title, revision.Content)
+}
+
+// postDiffText puts the title on the first line so title edits show up in
+// the diff too.
+func postDiffText(title string, content string) string {
+	return title + "\n\n" + content
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"myapp/model"
+	"myapp/tools"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const postSlugMaxLength = 80
+
+// postUniqueSlug derives a slug from title that no other post uses, either as
+// its current slug or as a redirect, adding -2, -3, ... on collision.
+func (s *Service) postUniqueSlug(ctx context.Context, title string, postID int) string {
+	base := tools.SlugifyASCII(title, postSlugMaxLength)
+	if base == "" {
+		base = "post"
+	}
+
+	for i := 1; ; i++ {
+		candidate := base
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d", base, i)
+		}
+
+		var (
+			post     model.Post
+			redirect model.PostSlugRedirect
+			count    int64
+		)
+
+		if err := s.DB.Model(&post).Where("slug = ? AND id <> ?", candidate, postID).Count(&count).Error; err != nil {
+			panic(err)
+		}
+
+		if count > 0 {
+			continue
+		}
+
+		if err := s.DB.Model(&redirect).Where("slug = ? AND post_id <> ?", candidate, postID).Count(&count).Error; err != nil {
+			panic(err)
+		}
+
+		if count == 0 {
+			return candidate
+		}
+	}
+}
+
+// postSlugChange returns the slug for a post whose title becomes title. When
+// it differs from the current one the old slug is kept as a redirect.
+func (s *Service) postSlugChange(ctx context.Context, current *model.Post, title string) string {
+	if current.Title == title {
+		return current.Slug
+	}
+
+	slug := s.postUniqueSlug(ctx, title, current.ID)
+	if slug == current.Slug {
+		return slug
+	}
+
+	redirect := model.PostSlugRedirect{
+		PostID:    current.ID,
+		Slug:      current.Slug,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := s.DB.Model(&redirect).Create(&redirect).Error; err != nil {
+		panic(err)
+	}
+
+	// Going back to an earlier title reclaims its slug from the redirects.
+	if err := s.DB.Where("post_id = ? AND slug = ?", current.ID, slug).Delete(&model.PostSlugRedirect{}).Error; err != nil {
+		panic(err)
+	}
+
+	return slug
+}
+
+// PostResolveSlug returns the current slug of the post a slug points to,
+// following redirects left by title changes.
+func (s *Service) PostResolveSlug(ctx context.Context, slug string) (string, error) {
+	var (
+		post     model.Post
+		redirect model.PostSlugRedirect
+	)
+
+	err := s.DB.Model(&redirect).Where("slug = ?", slug).First(&redirect).Error
+	if err == nil {
+		if err := s.DB.Model(&post).Scopes(tools.IsDeletedAtNull, postReadableBy(ctx)).Where("id = ?", redirect.PostID).First(&post).Error; err == gorm.ErrRecordNotFound {
+			panic(tools.NewCustomError(404, "post not found"))
+		} else if err != nil {
+			panic(err)
+		}
+
+		return post.Slug, nil
+	} else if err != gorm.ErrRecordNotFound {
+		panic(err)
+	}
+
+	return slug, nil
+}
+
+func (s *Service) PostGetBySlug(ctx context.Context, slug string) (*model.Post, error) {
+	var (
+		post model.Post
+	)
+
+	if err := s.DB.Model(&post).Scopes(tools.IsDeletedAtNull, postReadableBy(ctx)).Select("id").Where("slug = ?", slug).First(&post).Error; err == gorm.ErrRecordNotFound {
+		panic(tools.NewCustomError(404, "post not found"))
+	} else if err != nil {
+		panic(err)
+	}
+
+	return s.PostGetByID(ctx, post.ID)
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"myapp/config"
+	"myapp/middleware"
+	"myapp/model"
+	"myapp/tools"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const postPurgeBatchSize = 100
+
+// postDependentTables are keyed by post_id and removed together with the post
+// when it is purged. Tables added for new per-post data belong here too.
+var postDependentTables = []string{
+	"post_revision",
+	"post_tag",
+	"post_category",
+	"comment",
+	"post_reaction",
+	"post_reaction_count",
+	"post_slug_redirect",
+	"attachment",
+	"timeline_entry",
+	"bookmark",
+	"post_mention",
+	"notification",
+}
+
+// StartPostTrashPurger hard deletes posts that have been in the trash longer
+// than retention, checking every interval. Like the publisher, rows are
+// claimed with SKIP LOCKED so several instances can run it side by side.
+func StartPostTrashPurger(interval time.Duration, retention time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			runPostTrashPurger(retention)
+		}
+	}()
+}
+
+func runPostTrashPurger(retention time.Duration) {
+	s := GetTransaction()
+	defer func() {
+		if r := recover(); r != nil {
+			err := s.Rollback(r)
+			log.Println("post trash purger:", err)
+		}
+	}()
+
+	count, _ := s.PostPurgeExpired(context.Background(), time.Now().UTC().Add(-retention))
+	s.Commit()
+
+	if count > 0 {
+		log.Printf("post trash purger: purged %d posts", count)
+	}
+}
+
+func (s *Service) PostPurgeExpired(ctx context.Context, deletedBefore time.Time) (int, error) {
+	var (
+		post model.Post
+		ids  []int
+	)
+
+	if err := s.DB.Model(&post).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).
+		Order("deleted_at").
+		Limit(postPurgeBatchSize).
+		Pluck("id", &ids).Error; err != nil {
+		panic(err)
+	}
+
+	s.postPurge(ids...)
+
+	return len(ids), nil
+}
+
+// PostGetTrash lists the caller's deleted posts, most recently deleted first.
+func (s *Service) PostGetTrash(ctx context.Context, filter model.PostTrashFilter) (*model.PostPage, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		posts   []*model.Post
+	)
+
+	if filter.Limit <= 0 {
+		filter.Limit = postDefaultLimit
+	} else if filter.Limit > postMaxLimit {
+		filter.Limit = postMaxLimit
+	}
+
+	query := s.DB.Model(&posts).Scopes(postPreload).Where("post.user_id = ? AND post.deleted_at IS NOT NULL", getUser.ID)
+
+	if filter.Cursor != "" {
+		cursor, err := tools.DecodeCursor(filter.Cursor)
+		if err != nil {
+			panic(err)
+		}
+
+		deletedAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil || cursor.Sort != "deleted_at:desc" {
+			panic(tools.NewCustomError(400, "Invalid cursor"))
+		}
+
+		query = query.Where("(post.deleted_at < ? OR (post.deleted_at = ? AND post.id < ?))", deletedAt, deletedAt, cursor.ID)
+	}
+
+	if err := query.Order("post.deleted_at desc").Order("post.id desc").Limit(filter.Limit + 1).Find(&posts).Error; err != nil {
+		panic(err)
+	}
+
+	page := model.PostPage{
+		Posts: posts,
+	}
+
+	if len(posts) > filter.Limit {
+		page.Posts = posts[:filter.Limit]
+		page.HasMore = true
+
+		last := page.Posts[len(page.Posts)-1]
+		page.NextCursor = tools.EncodeCursor(tools.Cursor{
+			Sort:  "deleted_at:desc",
+			Value: last.DeletedAt.UTC().Format(time.RFC3339Nano),
+			ID:    last.ID,
+		})
+	}
+
+	retention := config.GetTrashRetention()
+	for _, post := range page.Posts {
+		purgeAt := post.DeletedAt.Add(retention)
+		post.PurgeAt = &purgeAt
+	}
+
+	s.postDecorate(ctx, page.Posts...)
+
+	return &page, nil
+}
+
+// PostRestore moves one of the caller's deleted posts out of the trash.
+func (s *Service) PostRestore(ctx context.Context, id int) (*model.Post, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		post    model.Post
+	)
+
+	if err := s.DB.Model(&post).Where("id = ? AND deleted_at IS NOT NULL", id).First(&post).Error; err == gorm.ErrRecordNotFound {
+		panic(tools.NewCustomError(404, "Post not found in trash"))
+	} else if err != nil {
+		panic(err)
+	}
+
+	if post.UserID != getUser.ID {
+		panic(tools.NewCustomError(403, "You are not the author of this post"))
+	}
+
+	if err := s.DB.Model(&post).Where("id = ?", id).Omit("updated_at").Updates(map[string]interface{}{
+		"version":    gorm.Expr("version + 1"),
+		"deleted_at": nil,
+	}).Error; err != nil {
+		panic(err)
+	}
+
+	restored, _ := s.PostGetByID(ctx, id)
+	s.postIndex(restored)
+
+	return restored, nil
+}
+
+// PostPurgeByID permanently deletes a post, whether or not it is in the trash.
+func (s *Service) PostPurgeByID(ctx context.Context, id int) (string, error) {
+	var (
+		post model.Post
+	)
+
+	if err := s.DB.Model(&post).Where("id = ?", id).First(&post).Error; err == gorm.ErrRecordNotFound {
+		panic(tools.NewCustomError(404, "Post not found"))
+	} else if err != nil {
+		panic(err)
+	}
+
+	s.postPurge(id)
+	s.postNotifyPurged(ctx, &post)
+
+	return "Success", nil
+}
+
+// postNotifyPurged tells the author of post that an operator purged it. Edits
+// and soft deletes are only open to the author, so this is the one way a post
+// changes at the hands of another user and the only producer of post
+// notifications. Purges by the trash purger have no user and are not
+// notified. The post is gone by then, so the notification does not point at
+// it.
+func (s *Service) postNotifyPurged(ctx context.Context, post *model.Post) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+	)
+
+	if getUser == nil || getUser.ID == post.UserID {
+		return
+	}
+
+	actorID := getUser.ID
+
+	s.NotificationCreate(ctx, model.NewNotification{
+		UserID:  post.UserID,
+		Type:    model.NotificationTypePostDeleted,
+		Message: fmt.Sprintf("Your post %q was deleted by another user", post.Title),
+		ActorID: &actorID,
+	})
+}
+
+// postPurge hard deletes posts and everything hanging off them. Stored
+// attachment files no live attachment refers to anymore are handed to the
+// sweeper.
+func (s *Service) postPurge(ids ...int) {
+	var (
+		attachment model.Attachment
+		hashes     []string
+	)
+
+	if len(ids) == 0 {
+		return
+	}
+
+	if err := s.DB.Model(&attachment).Scopes(tools.IsDeletedAtNull).Where("post_id IN ?", ids).Distinct().Order("hash").Pluck("hash", &hashes).Error; err != nil {
+		panic(err)
+	}
+
+	for _, table := range postDependentTables {
+		if err := s.DB.Exec("DELETE FROM "+table+" WHERE post_id IN ?", ids).Error; err != nil {
+			panic(err)
+		}
+	}
+
+	if err := s.DB.Exec("DELETE FROM post WHERE id IN ?", ids).Error; err != nil {
+		panic(err)
+	}
+
+	for _, hash := range hashes {
+		s.attachmentBlobRelease(hash)
+	}
+
+	for _, id := range ids {
+		s.searchRemove(id)
+	}
+}
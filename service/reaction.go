@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"myapp/middleware"
+	"myapp/model"
+	"myapp/tools"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// PostReactionPut adds a reaction of the logged in user, doing nothing when
+// it already exists. The counter only moves when a row was actually
+// inserted, so concurrent or repeated requests keep it exact.
+func (s *Service) PostReactionPut(ctx context.Context, postID int, reactionType string) ([]*model.ReactionSummary, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+	)
+
+	reactionCheckType(reactionType)
+	s.PostGetByID(ctx, postID)
+
+	reaction := model.PostReaction{
+		PostID:    postID,
+		UserID:    getUser.ID,
+		Type:      reactionType,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	result := s.DB.Model(&reaction).Clauses(clause.OnConflict{DoNothing: true}).Create(&reaction)
+	if result.Error != nil {
+		panic(result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		if err := s.DB.Exec("INSERT INTO post_reaction_count (post_id, type, count) VALUES (?, ?, 1) ON DUPLICATE KEY UPDATE count = count + 1", postID, reactionType).Error; err != nil {
+			panic(err)
+		}
+	}
+
+	return s.PostReactionSummary(ctx, postID)
+}
+
+// PostReactionDelete removes a reaction of the logged in user, doing nothing
+// when it does not exist.
+func (s *Service) PostReactionDelete(ctx context.Context, postID int, reactionType string) ([]*model.ReactionSummary, error) {
+	var (
+		getUser  = middleware.AuthContext(ctx)
+		reaction model.PostReaction
+	)
+
+	reactionCheckType(reactionType)
+	s.PostGetByID(ctx, postID)
+
+	result := s.DB.Where("post_id = ? AND user_id = ? AND type = ?", postID, getUser.ID, reactionType).Delete(&reaction)
+	if result.Error != nil {
+		panic(result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		if err := s.DB.Exec("UPDATE post_reaction_count SET count = count - 1 WHERE post_id = ? AND type = ? AND count > 0", postID, reactionType).Error; err != nil {
+			panic(err)
+		}
+	}
+
+	return s.PostReactionSummary(ctx, postID)
+}
+
+func (s *Service) PostReactionSummary(ctx context.Context, postID int) ([]*model.ReactionSummary, error) {
+	summaries, _ := s.PostReactionSummaries(ctx, []int{postID})
+
+	return summaries[postID], nil
+}
+
+// PostReactionSummaries reads the reaction counters of several posts at once,
+// flagging the types the logged in user reacted with.
+func (s *Service) PostReactionSummaries(ctx context.Context, postIDs []int) (map[int][]*model.ReactionSummary, error) {
+	var (
+		getUser   = middleware.AuthContext(ctx)
+		counts    []*model.PostReactionCount
+		reactions []*model.PostReaction
+		mine      = map[int]map[string]bool{}
+		summaries = map[int][]*model.ReactionSummary{}
+	)
+
+	for _, id := range postIDs {
+		summaries[id] = []*model.ReactionSummary{}
+	}
+
+	if len(postIDs) == 0 {
+		return summaries, nil
+	}
+
+	if err := s.DB.Model(&counts).Where("post_id IN ? AND count > 0", postIDs).Find(&counts).Error; err != nil {
+		panic(err)
+	}
+
+	if getUser != nil {
+		if err := s.DB.Model(&reactions).Where("post_id IN ? AND user_id = ?", postIDs, getUser.ID).Find(&reactions).Error; err != nil {
+			panic(err)
+		}
+
+		for _, reaction := range reactions {
+			if mine[reaction.PostID] == nil {
+				mine[reaction.PostID] = map[string]bool{}
+			}
+			mine[reaction.PostID][reaction.Type] = true
+		}
+	}
+
+	byPost := map[int]map[string]int{}
+	for _, count := range counts {
+		if byPost[count.PostID] == nil {
+			byPost[count.PostID] = map[string]int{}
+		}
+		byPost[count.PostID][count.Type] = count.Count
+	}
+
+	for _, id := range postIDs {
+		for _, reactionType := range model.ReactionTypes {
+			count := byPost[id][reactionType]
+			if count == 0 {
+				continue
+			}
+
+			summaries[id] = append(summaries[id], &model.ReactionSummary{
+				Type:        reactionType,
+				Count:       count,
+				ReactedByMe: mine[id][reactionType],
+			})
+		}
+	}
+
+	return summaries, nil
+}
+
+func reactionCheckType(reactionType string) {
+	for _, t := range model.ReactionTypes {
+		if t == reactionType {
+			return
+		}
+	}
+
+	panic(tools.NewCustomError(400, "Invalid reaction type"))
+}
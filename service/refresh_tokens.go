@@ -2,29 +2,133 @@ package service
 
 import (
 	"context"
-	"fmt"
+	"myapp/middleware"
 	"myapp/model"
 	"myapp/tools"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// RefreshTokensCreate stores a refresh token. Without a FamilyID the token
+// starts a new family named after its own id.
 func (s *Service) RefreshTokensCreate(ctx context.Context, refreshToken model.RefreshTokens) (*model.RefreshTokens, error) {
 	if err := s.DB.Model(&refreshToken).Create(&refreshToken).Error; err != nil {
 		panic(err)
 	}
 
+	if refreshToken.FamilyID == 0 {
+		refreshToken.FamilyID = refreshToken.ID
+
+		if err := s.DB.Model(&refreshToken).Where("id = ?", refreshToken.ID).Update("family_id", refreshToken.FamilyID).Error; err != nil {
+			panic(err)
+		}
+	}
+
 	return &refreshToken, nil
 }
 
+type refreshTokenState int
+
+const (
+	refreshTokenValid refreshTokenState = iota
+	refreshTokenReused
+	refreshTokenInvalid
+)
+
+// refreshTokenCheck tells what presenting refreshToken at now amounts to. A
+// replaced token is reuse whether or not it has expired since, any other
+// revoked or expired token is simply invalid.
+func refreshTokenCheck(refreshToken *model.RefreshTokens, now time.Time) refreshTokenState {
+	if refreshToken.ReplacedByID != nil {
+		return refreshTokenReused
+	}
+
+	if refreshToken.RevokedAt != nil || !refreshToken.ExpiredAt.After(now) {
+		return refreshTokenInvalid
+	}
+
+	return refreshTokenValid
+}
+
+// RefreshTokensGenerateAccessToken rotates the refresh token: the one
+// presented is revoked and replaced by a new one of the same family, handed
+// back with the new access token.
+//
+// A refresh token that was already replaced can only be presented again by
+// someone holding a copy of it, so the whole family and its access tokens are
+// revoked and a security event is recorded. That error is returned rather than
+// panicked, the caller still has to commit the revocation.
 func (s *Service) RefreshTokensGenerateAccessToken(ctx context.Context, refreshToken string) (*model.TokenDataResponse, error) {
-	refreshTokenData, _ := s.RefreshTokensGetByRawToken(ctx, refreshToken)
+	var (
+		refreshTokenData model.RefreshTokens
+	)
+
+	if err := s.DB.Model(&refreshTokenData).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tools.HashSHA256(refreshToken)).
+		Take(&refreshTokenData).Error; err == gorm.ErrRecordNotFound {
+		panic(tools.NewCustomError(401, "Invalid refresh token"))
+	} else if err != nil {
+		panic(err)
+	}
+
+	switch refreshTokenCheck(&refreshTokenData, time.Now().UTC()) {
+	case refreshTokenReused:
+		s.refreshTokensRevokeFamily(ctx, &refreshTokenData)
+		return nil, tools.NewCustomError(401, "Refresh token reuse detected, the session has been revoked")
+	case refreshTokenInvalid:
+		panic(tools.NewCustomError(401, "Invalid refresh token"))
+	}
+
+	s.refreshTokensCheckClient(ctx, &refreshTokenData)
 
-	accessToken, accessExpiredAt := tools.TokenCreate(refreshTokenData.UserID)
+	replacement, tokens := s.refreshTokensIssue(ctx, refreshTokenData.UserID, refreshTokenData.FamilyID, refreshTokenData.DeviceLabel)
+
+	if err := s.DB.Model(&refreshTokenData).Where("id = ?", refreshTokenData.ID).Updates(map[string]interface{}{
+		"revoked_at":     time.Now().UTC(),
+		"replaced_by_id": replacement.ID,
+	}).Error; err != nil {
+		panic(err)
+	}
+
+	return tokens, nil
+}
+
+// refreshTokensIssue creates a refresh token in familyID, or in a new family
+// when it is 0, and a first access token for it. The client of the request is
+// recorded on the refresh token, deviceLabel defaults to a name guessed from
+// its user agent.
+func (s *Service) refreshTokensIssue(ctx context.Context, userID int, familyID int, deviceLabel string) (*model.RefreshTokens, *model.TokenDataResponse) {
+	accessToken, accessExpiredAt := tools.TokenCreate(userID)
+
+	refreshToken, err := tools.GenerateSecureTokenHex(32)
+	if err != nil {
+		panic(err)
+	}
+
+	client := middleware.ClientContext(ctx)
+	now := time.Now().UTC()
+
+	inputRefreshTokenData := model.RefreshTokens{
+		UserID:      userID,
+		FamilyID:    familyID,
+		TokenHash:   tools.HashSHA256(refreshToken),
+		CreatedAt:   now,
+		ExpiredAt:   now.AddDate(0, 0, 7),
+		UserAgent:   clientUserAgent(client),
+		IPAddress:   client.IPAddress,
+		DeviceLabel: sessionDeviceLabel(deviceLabel, client),
+		LastUsedAt:  &now,
+	}
+
+	refreshTokenData, _ := s.RefreshTokensCreate(ctx, inputRefreshTokenData)
 
 	inputAccessTokenData := model.AccessTokens{
-		UserID:         refreshTokenData.UserID,
+		UserID:         userID,
 		RefreshTokenID: refreshTokenData.ID,
 		TokenHash:      tools.HashSHA256(accessToken),
 		CreatedAt:      time.Now().UTC(),
@@ -33,24 +137,127 @@ func (s *Service) RefreshTokensGenerateAccessToken(ctx context.Context, refreshT
 
 	s.AccessTokensCreate(ctx, inputAccessTokenData)
 
-	return &model.TokenDataResponse{
+	return refreshTokenData, &model.TokenDataResponse{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
-	}, nil
+	}
+}
+
+// refreshTokensRevokeFamily revokes every token descending from the same
+// login as refreshToken, records the reuse and tells the user. A family that
+// is already revoked was reported the first time, presenting its tokens again
+// is only refused.
+func (s *Service) refreshTokensRevokeFamily(ctx context.Context, refreshToken *model.RefreshTokens) {
+	var (
+		client   = middleware.ClientContext(ctx)
+		familyID = refreshToken.FamilyID
+		live     int64
+	)
+
+	if err := s.DB.Model(&model.RefreshTokens{}).Where("family_id = ? AND revoked_at IS NULL", familyID).Count(&live).Error; err != nil {
+		panic(err)
+	}
+
+	if live == 0 {
+		return
+	}
+
+	s.RefreshTokensRevokeFamily(ctx, familyID)
+
+	event := model.SecurityEvent{
+		UserID:               refreshToken.UserID,
+		Type:                 model.SecurityEventRefreshTokenReuse,
+		RefreshTokenFamilyID: &familyID,
+		UserAgent:            clientUserAgent(client),
+		IPAddress:            client.IPAddress,
+		CreatedAt:            time.Now().UTC(),
+	}
+
+	if err := s.DB.Model(&event).Create(&event).Error; err != nil {
+		panic(err)
+	}
+
+	s.NotificationCreate(ctx, model.NewNotification{
+		UserID:  refreshToken.UserID,
+		Type:    model.NotificationTypeRefreshReuse,
+		Message: "An old session token was used again from " + clientDescription(client) + ", the session was signed out. Sign in again if this was not you.",
+	})
 }
 
-func (s *Service) RefreshTokensGetByRawToken(ctx context.Context, token string) (*model.RefreshTokens, error) {
+// refreshTokensCheckClient tells the user when a refresh token is used from
+// another client than the one it was issued to, its replacement then records
+// the new client. Clients are told apart by user agent only, IP addresses
+// change too often on mobile networks to be worth a notification.
+func (s *Service) refreshTokensCheckClient(ctx context.Context, refreshToken *model.RefreshTokens) {
+	var (
+		client = middleware.ClientContext(ctx)
+	)
+
+	// Tokens issued before clients were recorded have no user agent yet.
+	if refreshToken.UserAgent == "" || clientUserAgent(client) == refreshToken.UserAgent {
+		return
+	}
+
+	s.NotificationCreate(ctx, model.NewNotification{
+		UserID:  refreshToken.UserID,
+		Type:    model.NotificationTypeRefreshNewClient,
+		Message: "Your session was used from a new client: " + clientDescription(client),
+	})
+}
+
+// clientUserAgent fits the user agent of client into its column.
+func clientUserAgent(client *middleware.Client) string {
+	userAgent := strings.ToValidUTF8(client.UserAgent, "")
+	if utf8.RuneCountInString(userAgent) > 255 {
+		userAgent = string([]rune(userAgent)[:255])
+	}
+
+	return userAgent
+}
+
+func clientDescription(client *middleware.Client) string {
+	userAgent := client.UserAgent
+	if userAgent == "" {
+		userAgent = "an unknown client"
+	}
+
+	if client.IPAddress == "" {
+		return userAgent
+	}
+
+	return userAgent + " (" + client.IPAddress + ")"
+}
+
+// RefreshTokensRevokeFamily revokes every refresh token descending from one
+// login together with the access tokens issued from them.
+func (s *Service) RefreshTokensRevokeFamily(ctx context.Context, familyID int) error {
 	var (
 		refreshToken model.RefreshTokens
+		now          = time.Now().UTC()
 	)
 
-	hashedToken := tools.HashSHA256(token)
+	if err := s.DB.Exec(`UPDATE access_tokens SET revoked_at = ?
+		WHERE revoked_at IS NULL AND refresh_token_id IN (SELECT id FROM refresh_tokens WHERE family_id = ?)`, now, familyID).Error; err != nil {
+		panic(err)
+	}
 
-	if err := s.DB.Model(&refreshToken).Where("token_hash = ? AND expired_at > ? AND revoked_at IS NULL", hashedToken, time.Now().UTC()).Take(&refreshToken).Error; err == gorm.ErrRecordNotFound {
-		panic(fmt.Errorf("invalid refresh token"))
-	} else if err != nil {
+	if err := s.DB.Model(&refreshToken).Where("family_id = ? AND revoked_at IS NULL", familyID).Update("revoked_at", now).Error; err != nil {
 		panic(err)
 	}
 
-	return &refreshToken, nil
+	return nil
+}
+
+func (s *Service) RefreshTokensRevokeByUserID(ctx context.Context, userID int) error {
+	var (
+		refreshToken model.RefreshTokens
+	)
+
+	if err := s.DB.Model(&refreshToken).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", time.Now().UTC()).Error; err != nil {
+		panic(err)
+	}
+
+	s.AccessTokensRevokeByUserID(ctx, userID)
+
+	return nil
 }
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"myapp/model"
+	"testing"
+	"time"
+)
+
+func TestRefreshTokenCheck(t *testing.T) {
+	var (
+		now         = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		replacement = 2
+	)
+
+	tests := []struct {
+		name  string
+		token model.RefreshTokens
+		want  refreshTokenState
+	}{
+		{
+			name:  "live",
+			token: model.RefreshTokens{ExpiredAt: now.Add(time.Hour)},
+			want:  refreshTokenValid,
+		},
+		{
+			name:  "expired",
+			token: model.RefreshTokens{ExpiredAt: now.Add(-time.Second)},
+			want:  refreshTokenInvalid,
+		},
+		{
+			name:  "expires now",
+			token: model.RefreshTokens{ExpiredAt: now},
+			want:  refreshTokenInvalid,
+		},
+		{
+			name:  "revoked by logout",
+			token: model.RefreshTokens{ExpiredAt: now.Add(time.Hour), RevokedAt: &now},
+			want:  refreshTokenInvalid,
+		},
+		{
+			name:  "rotated",
+			token: model.RefreshTokens{ExpiredAt: now.Add(time.Hour), RevokedAt: &now, ReplacedByID: &replacement},
+			want:  refreshTokenReused,
+		},
+		{
+			name:  "rotated and expired since",
+			token: model.RefreshTokens{ExpiredAt: now.Add(-time.Hour), RevokedAt: &now, ReplacedByID: &replacement},
+			want:  refreshTokenReused,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := refreshTokenCheck(&tt.token, now); got != tt.want {
+				t.Fatalf("refreshTokenCheck() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"html"
+	"log"
+	"myapp/config"
+	"myapp/model"
+	"myapp/tools"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+const (
+	searchDefaultLimit = 20
+	searchMaxLimit     = 100
+	searchSnippetSize  = 160
+
+	// searchChangeRetention is how long the search_change log is kept, far
+	// longer than any refresh interval.
+	searchChangeRetention = time.Hour
+)
+
+type PostSearchScore struct {
+	ID    int
+	Score float64
+}
+
+// PostSearcher is a full-text index over post titles and content.
+type PostSearcher interface {
+	// Load rebuilds the index from the database on startup.
+	Load(db *gorm.DB) error
+	Index(post *model.Post)
+	Remove(id int)
+	// Search ranks the posts matching query and returns a page of them.
+	// Only posts the logged in user of ctx may list, as postVisibleTo
+	// defines, are ranked, so pages are full and offsets stay stable.
+	Search(ctx context.Context, db *gorm.DB, query string, limit int, offset int) ([]PostSearchScore, error)
+}
+
+// postSearchRefresher is a searcher holding its own copy of the index in the
+// process. Every post written is logged to search_change, Refresh applies the
+// changes other server instances made since the last call.
+type postSearchRefresher interface {
+	Refresh(db *gorm.DB) error
+}
+
+var searcher PostSearcher
+
+// SearchInit picks the configured search backend and loads it.
+func SearchInit() {
+	switch config.GetSearchBackend() {
+	case "memory":
+		searcher = newMemoryPostSearcher()
+	case "mysql":
+		searcher = &mysqlPostSearcher{}
+	default:
+		panic("unknown SEARCH_BACKEND " + config.GetSearchBackend())
+	}
+
+	if err := searcher.Load(config.GetDB()); err != nil {
+		panic(err)
+	}
+}
+
+// StartSearchRefresher keeps an in-process search index in step with the
+// other server instances, applying their changes every interval. Searchers
+// backed by the database need nothing and it does not start.
+func StartSearchRefresher(interval time.Duration) {
+	refresher, ok := searcher.(postSearchRefresher)
+	if !ok {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			runSearchRefresher(refresher)
+		}
+	}()
+}
+
+func runSearchRefresher(refresher postSearchRefresher) {
+	db := config.GetDB()
+
+	if err := refresher.Refresh(db); err != nil {
+		log.Println("search refresher:", err)
+		return
+	}
+
+	if err := db.Where("created_at < ?", time.Now().UTC().Add(-searchChangeRetention)).Delete(&model.SearchChange{}).Error; err != nil {
+		log.Println("search refresher:", err)
+	}
+}
+
+func (s *Service) searchIndex(post *model.Post) {
+	if searcher == nil {
+		return
+	}
+
+	s.searchChanged(post.ID)
+
+	indexed := *post
+	s.AfterCommit(func() {
+		searcher.Index(&indexed)
+	})
+}
+
+func (s *Service) searchRemove(id int) {
+	if searcher == nil {
+		return
+	}
+
+	s.searchChanged(id)
+
+	s.AfterCommit(func() {
+		searcher.Remove(id)
+	})
+}
+
+// searchChanged logs a post change for the other instances to refresh, with
+// the transaction so a rolled back write is never applied.
+func (s *Service) searchChanged(postID int) {
+	if _, ok := searcher.(postSearchRefresher); !ok {
+		return
+	}
+
+	change := model.SearchChange{
+		PostID:    postID,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := s.DB.Model(&change).Create(&change).Error; err != nil {
+		panic(err)
+	}
+}
+
+func (s *Service) PostSearch(ctx context.Context, input model.PostSearchInput) ([]*model.PostSearchHit, error) {
+	var (
+		posts []*model.Post
+		hits  []*model.PostSearchHit
+	)
+
+	terms := searchTokenize(input.Query)
+	if len(terms) == 0 {
+		panic(tools.NewCustomError(400, "Invalid search query"))
+	}
+
+	if input.Limit <= 0 {
+		input.Limit = searchDefaultLimit
+	} else if input.Limit > searchMaxLimit {
+		input.Limit = searchMaxLimit
+	}
+
+	if input.Offset < 0 {
+		input.Offset = 0
+	}
+
+	scores, err := searcher.Search(ctx, s.DB, input.Query, input.Limit, input.Offset)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(scores) == 0 {
+		return []*model.PostSearchHit{}, nil
+	}
+
+	ids := make([]int, 0, len(scores))
+	for _, score := range scores {
+		ids = append(ids, score.ID)
+	}
+
+	// Visibility is checked again in case a post changed since the index saw
+	// it.
+	if err := s.DB.Model(&posts).Scopes(tools.IsDeletedAtNull, postVisibleTo(ctx), postPreload).Where("id IN ?", ids).Find(&posts).Error; err != nil {
+		panic(err)
+	}
+
+	s.postDecorate(ctx, posts...)
+
+	byID := make(map[int]*model.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+
+	for _, score := range scores {
+		post, ok := byID[score.ID]
+		if !ok {
+			continue
+		}
+
+		hits = append(hits, &model.PostSearchHit{
+			Post:             post,
+			Score:            score.Score,
+			TitleHighlight:   searchHighlight(post.Title, terms, 0),
+			ContentHighlight: searchHighlight(post.Content, terms, searchSnippetSize),
+		})
+	}
+
+	return hits, nil
+}
+
+// searchTokenize lowercases input and splits it on anything that is not a
+// letter or digit.
+func searchTokenize(input string) []string {
+	return strings.FieldsFunc(strings.ToLower(input), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// searchHighlight HTML-escapes text and wraps query terms in <mark>. When size
+// is positive the text is cut to a window of about size runes around the first
+// match.
+func searchHighlight(text string, terms []string, size int) string {
+	type span struct {
+		start, end int
+	}
+
+	var (
+		runes = []rune(text)
+		lower = []rune(strings.ToLower(text))
+		spans []span
+		want  = make(map[string]bool, len(terms))
+	)
+
+	// Lowercasing can change rune count for a few scripts, fall back to the
+	// original text rather than highlight at wrong offsets.
+	if len(lower) != len(runes) {
+		lower = runes
+	}
+
+	for _, term := range terms {
+		want[term] = true
+	}
+
+	for i := 0; i < len(lower); {
+		if !unicode.IsLetter(lower[i]) && !unicode.IsDigit(lower[i]) {
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(lower) && (unicode.IsLetter(lower[j]) || unicode.IsDigit(lower[j])) {
+			j++
+		}
+
+		if want[string(lower[i:j])] {
+			spans = append(spans, span{i, j})
+		}
+		i = j
+	}
+
+	from, to := 0, len(runes)
+	if size > 0 && len(runes) > size {
+		if len(spans) > 0 {
+			from = spans[0].start - size/4
+			if from < 0 {
+				from = 0
+			}
+		}
+
+		to = from + size
+		if to > len(runes) {
+			to = len(runes)
+			from = to - size
+		}
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+
+	pos := from
+	for _, sp := range spans {
+		if sp.start < from || sp.end > to {
+			continue
+		}
+
+		b.WriteString(html.EscapeString(string(runes[pos:sp.start])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[sp.start:sp.end])))
+		b.WriteString("</mark>")
+		pos = sp.end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:to])))
+
+	if to < len(runes) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"math"
+	"myapp/middleware"
+	"myapp/model"
+	"myapp/tools"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// Title terms are counted this many times so title matches rank higher.
+	searchTitleBoost = 2
+
+	// searchRefreshOverlap is how far back a refresh looks again, so a
+	// change logged by a transaction that committed late, or by an instance
+	// whose clock is behind, is still seen.
+	searchRefreshOverlap = time.Minute
+)
+
+// memoryPostSearcher is an in-process inverted index ranked with BM25, for
+// deployments whose database has no FULLTEXT support. Each server instance
+// has its own, kept in step with the others through Refresh.
+type memoryPostSearcher struct {
+	mu       sync.RWMutex
+	postings map[string]map[int]int
+	docTerms map[int]map[string]int
+	docLen   map[int]int
+	docs     map[int]memorySearchDoc
+	totalLen int
+
+	// Only used by Load and Refresh, which never run at the same time.
+	refreshedTo time.Time
+	applied     map[int64]time.Time
+}
+
+// memorySearchDoc is what the index needs to know about a post to tell who
+// may find it.
+type memorySearchDoc struct {
+	userID     int
+	status     string
+	visibility string
+}
+
+func newMemoryPostSearcher() *memoryPostSearcher {
+	return &memoryPostSearcher{
+		postings: map[string]map[int]int{},
+		docTerms: map[int]map[string]int{},
+		docLen:   map[int]int{},
+		docs:     map[int]memorySearchDoc{},
+		applied:  map[int64]time.Time{},
+	}
+}
+
+// visibleTo is postVisibleTo for an indexed post: public posts, plus every
+// post of userID. A userID of 0 is a visitor.
+func (d memorySearchDoc) visibleTo(userID int) bool {
+	if d.status == model.PostStatusPublished && d.visibility == model.PostVisibilityPublic {
+		return true
+	}
+
+	return userID != 0 && d.userID == userID
+}
+
+func (m *memoryPostSearcher) Load(db *gorm.DB) error {
+	var (
+		posts []*model.Post
+	)
+
+	// Changes logged while loading are applied again by the first refresh.
+	m.refreshedTo = time.Now().UTC()
+
+	return db.Model(&posts).Scopes(tools.IsDeletedAtNull).FindInBatches(&posts, 500, func(tx *gorm.DB, batch int) error {
+		for _, post := range posts {
+			m.Index(post)
+		}
+
+		return nil
+	}).Error
+}
+
+// Refresh reindexes the posts written since the last refresh, by this
+// instance or any other, as logged in search_change. Changes inside the
+// overlap window are read again but applied once.
+func (m *memoryPostSearcher) Refresh(db *gorm.DB) error {
+	var (
+		changes []*model.SearchChange
+		posts   []*model.Post
+		ids     []int
+		found   = map[int]bool{}
+	)
+
+	from := m.refreshedTo.Add(-searchRefreshOverlap)
+
+	if err := db.Model(&changes).Where("created_at >= ?", from).Order("id").Find(&changes).Error; err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		if _, ok := m.applied[change.ID]; ok {
+			continue
+		}
+
+		ids = append(ids, change.PostID)
+	}
+
+	if len(ids) > 0 {
+		if err := db.Model(&posts).Scopes(tools.IsDeletedAtNull).Where("id IN ?", ids).Find(&posts).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, post := range posts {
+		found[post.ID] = true
+		m.Index(post)
+	}
+
+	for _, id := range ids {
+		if !found[id] {
+			m.Remove(id)
+		}
+	}
+
+	for _, change := range changes {
+		m.applied[change.ID] = change.CreatedAt
+		if change.CreatedAt.After(m.refreshedTo) {
+			m.refreshedTo = change.CreatedAt
+		}
+	}
+
+	for id, createdAt := range m.applied {
+		if createdAt.Before(from) {
+			delete(m.applied, id)
+		}
+	}
+
+	return nil
+}
+
+func (m *memoryPostSearcher) Index(post *model.Post) {
+	terms := map[string]int{}
+	length := 0
+
+	for _, term := range searchTokenize(post.Title) {
+		terms[term] += searchTitleBoost
+		length += searchTitleBoost
+	}
+
+	for _, term := range searchTokenize(post.Content) {
+		terms[term]++
+		length++
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(post.ID)
+
+	for term, tf := range terms {
+		if m.postings[term] == nil {
+			m.postings[term] = map[int]int{}
+		}
+		m.postings[term][post.ID] = tf
+	}
+
+	m.docTerms[post.ID] = terms
+	m.docLen[post.ID] = length
+	m.docs[post.ID] = memorySearchDoc{
+		userID:     post.UserID,
+		status:     post.Status,
+		visibility: post.Visibility,
+	}
+	m.totalLen += length
+}
+
+func (m *memoryPostSearcher) Remove(id int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(id)
+}
+
+func (m *memoryPostSearcher) remove(id int) {
+	terms, ok := m.docTerms[id]
+	if !ok {
+		return
+	}
+
+	for term := range terms {
+		delete(m.postings[term], id)
+		if len(m.postings[term]) == 0 {
+			delete(m.postings, term)
+		}
+	}
+
+	m.totalLen -= m.docLen[id]
+	delete(m.docTerms, id)
+	delete(m.docLen, id)
+	delete(m.docs, id)
+}
+
+func (m *memoryPostSearcher) Search(ctx context.Context, db *gorm.DB, query string, limit int, offset int) ([]PostSearchScore, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		viewer  int
+	)
+
+	if getUser != nil {
+		viewer = getUser.ID
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	total := len(m.docLen)
+	if total == 0 {
+		return nil, nil
+	}
+
+	avgLen := float64(m.totalLen) / float64(total)
+	acc := map[int]float64{}
+	seen := map[string]bool{}
+
+	for _, term := range searchTokenize(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		docs := m.postings[term]
+		df := float64(len(docs))
+		idf := math.Log(1 + (float64(total)-df+0.5)/(df+0.5))
+
+		for id, tf := range docs {
+			if !m.docs[id].visibleTo(viewer) {
+				continue
+			}
+
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(m.docLen[id])/avgLen)
+			acc[id] += idf * float64(tf) * (bm25K1 + 1) / (float64(tf) + norm)
+		}
+	}
+
+	scores := make([]PostSearchScore, 0, len(acc))
+	for id, score := range acc {
+		scores = append(scores, PostSearchScore{ID: id, Score: score})
+	}
+	sortSearchScores(scores)
+
+	if offset >= len(scores) {
+		return nil, nil
+	}
+
+	scores = scores[offset:]
+	if len(scores) > limit {
+		scores = scores[:limit]
+	}
+
+	return scores, nil
+}
+
+func sortSearchScores(scores []PostSearchScore) {
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+
+		return scores[i].ID > scores[j].ID
+	})
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"myapp/model"
+
+	"gorm.io/gorm"
+)
+
+// mysqlPostSearcher relies on the ft_post_title_content FULLTEXT index, which
+// MySQL keeps in sync with the post table by itself.
+type mysqlPostSearcher struct{}
+
+func (m *mysqlPostSearcher) Load(db *gorm.DB) error {
+	return nil
+}
+
+func (m *mysqlPostSearcher) Index(post *model.Post) {}
+
+func (m *mysqlPostSearcher) Remove(id int) {}
+
+func (m *mysqlPostSearcher) Search(ctx context.Context, db *gorm.DB, query string, limit int, offset int) ([]PostSearchScore, error) {
+	var (
+		post   model.Post
+		scores []PostSearchScore
+	)
+
+	match := "MATCH(title, content) AGAINST (? IN NATURAL LANGUAGE MODE)"
+
+	if err := db.Model(&post).
+		Select("id, "+match+" AS score", query).
+		Scopes(postVisibleTo(ctx)).
+		Where("deleted_at IS NULL").
+		Where(match, query).
+		Order("score DESC").
+		Order("id DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&scores).Error; err != nil {
+		return nil, err
+	}
+
+	return scores, nil
+}
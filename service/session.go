@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"myapp/middleware"
+	"myapp/model"
+	"myapp/tools"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const sessionDeviceLabelSize = 100
+
+type sessionRow struct {
+	model.RefreshTokens
+	SignedInAt time.Time
+}
+
+// SessionGetAll lists where the logged in user is signed in, most recently
+// used first. A session is the live refresh token of a token family, the one
+// the request was made from is marked current.
+func (s *Service) SessionGetAll(ctx context.Context) ([]*model.Session, error) {
+	var (
+		getUser  = middleware.AuthContext(ctx)
+		rows     []*sessionRow
+		sessions = []*model.Session{}
+	)
+
+	if err := s.DB.Model(&model.RefreshTokens{}).
+		Scopes(tools.IsRefreshTokenActive).
+		Select("refresh_tokens.*, (SELECT root.created_at FROM refresh_tokens root WHERE root.id = refresh_tokens.family_id) AS signed_in_at").
+		Where("refresh_tokens.user_id = ?", getUser.ID).
+		Order("refresh_tokens.last_used_at desc").
+		Order("refresh_tokens.id desc").
+		Scan(&rows).Error; err != nil {
+		panic(err)
+	}
+
+	currentID := s.sessionCurrentID(ctx)
+
+	for _, row := range rows {
+		sessions = append(sessions, &model.Session{
+			ID:          row.FamilyID,
+			DeviceLabel: row.DeviceLabel,
+			UserAgent:   row.UserAgent,
+			IPAddress:   row.IPAddress,
+			SignedInAt:  row.SignedInAt,
+			LastUsedAt:  row.LastUsedAt,
+			ExpiredAt:   row.ExpiredAt,
+			Current:     row.FamilyID == currentID,
+		})
+	}
+
+	return sessions, nil
+}
+
+// SessionDeleteByID signs one of the logged in user's sessions out, revoking
+// its refresh and access tokens. Deleting the current session is a logout.
+func (s *Service) SessionDeleteByID(ctx context.Context, id int) (string, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		count   int64
+	)
+
+	if err := s.DB.Model(&model.RefreshTokens{}).Scopes(tools.IsRefreshTokenActive).Where("family_id = ? AND user_id = ?", id, getUser.ID).Count(&count).Error; err != nil {
+		panic(err)
+	}
+
+	if count == 0 {
+		panic(tools.NewCustomError(404, "Session not found"))
+	}
+
+	s.RefreshTokensRevokeFamily(ctx, id)
+
+	return "Success", nil
+}
+
+// sessionCurrentID is the session the access token of the request belongs
+// to.
+func (s *Service) sessionCurrentID(ctx context.Context) int {
+	var (
+		getUser  = middleware.AuthContext(ctx)
+		familyID []int
+	)
+
+	if err := s.DB.Model(&model.RefreshTokens{}).
+		Joins("JOIN access_tokens ON access_tokens.refresh_token_id = refresh_tokens.id").
+		Where("access_tokens.token_hash = ?", getUser.TokenHash).
+		Limit(1).
+		Pluck("refresh_tokens.family_id", &familyID).Error; err != nil {
+		panic(err)
+	}
+
+	if len(familyID) == 0 {
+		return 0
+	}
+
+	return familyID[0]
+}
+
+// sessionDeviceLabel cleans up the label a client gave its session, or names
+// the client from its user agent.
+func sessionDeviceLabel(label string, client *middleware.Client) string {
+	label = strings.TrimSpace(strings.ToValidUTF8(label, ""))
+	if label == "" {
+		label = tools.DeviceLabel(client.UserAgent)
+	}
+
+	if utf8.RuneCountInString(label) > sessionDeviceLabelSize {
+		label = string([]rune(label)[:sessionDeviceLabelSize])
+	}
+
+	return label
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"myapp/model"
+	"myapp/tools"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	tagMaxPerPost = 20
+	tagMaxLength  = 50
+
+	// tagNameMaxLength is the size of the name column.
+	tagNameMaxLength = 64
+
+	tagDefaultLimit = 100
+	tagMaxLimit     = 500
+)
+
+// TagGetAll lists tags by the number of published posts using them, a page
+// at a time.
+func (s *Service) TagGetAll(ctx context.Context, filter model.TagFilter) (*model.TagPage, error) {
+	var (
+		tags []*model.TagCount
+	)
+
+	if filter.Limit <= 0 {
+		filter.Limit = tagDefaultLimit
+	} else if filter.Limit > tagMaxLimit {
+		filter.Limit = tagMaxLimit
+	}
+
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	if err := s.DB.Table("tag").
+		Select("tag.id, tag.name, tag.slug, COUNT(post.id) AS post_count").
+		Joins("LEFT JOIN post_tag ON post_tag.tag_id = tag.id").
+		Joins("LEFT JOIN post ON post.id = post_tag.post_id AND post.deleted_at IS NULL AND post.status = ? AND post.visibility = ?", model.PostStatusPublished, model.PostVisibilityPublic).
+		Group("tag.id").
+		Order("post_count DESC").
+		Order("tag.slug").
+		Limit(filter.Limit + 1).
+		Offset(filter.Offset).
+		Scan(&tags).Error; err != nil {
+		panic(err)
+	}
+
+	page := model.TagPage{
+		Tags: tags,
+	}
+
+	if len(tags) > filter.Limit {
+		page.Tags = tags[:filter.Limit]
+		page.HasMore = true
+	}
+
+	return &page, nil
+}
+
+// TagGetOrCreate resolves tag names by slug, creating the missing ones.
+func (s *Service) TagGetOrCreate(ctx context.Context, names []string) ([]*model.Tag, error) {
+	var (
+		tags  []*model.Tag
+		slugs []string
+		byTag = map[string]string{}
+	)
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		slug := tools.Slugify(name)
+
+		if slug == "" || len(slug) > tagMaxLength || utf8.RuneCountInString(name) > tagNameMaxLength {
+			panic(tools.NewCustomError(400, "Invalid tag "+name))
+		}
+
+		if _, ok := byTag[slug]; ok {
+			continue
+		}
+		byTag[slug] = name
+		slugs = append(slugs, slug)
+	}
+
+	if len(slugs) > tagMaxPerPost {
+		panic(tools.NewCustomError(400, "Too many tags"))
+	}
+
+	for _, slug := range slugs {
+		tag := model.Tag{
+			Name:      byTag[slug],
+			Slug:      slug,
+			CreatedAt: time.Now().UTC(),
+		}
+
+		if err := s.DB.Model(&tag).Clauses(clause.OnConflict{DoNothing: true}).Create(&tag).Error; err != nil {
+			panic(err)
+		}
+	}
+
+	if len(slugs) == 0 {
+		return tags, nil
+	}
+
+	if err := s.DB.Model(&tags).Where("slug IN ?", slugs).Find(&tags).Error; err != nil {
+		panic(err)
+	}
+
+	return tags, nil
+}
+
+// PostTagsSet replaces the tags of a post.
+func (s *Service) PostTagsSet(ctx context.Context, postID int, names []string) error {
+	tags, _ := s.TagGetOrCreate(ctx, names)
+
+	if err := s.DB.Exec("DELETE FROM post_tag WHERE post_id = ?", postID).Error; err != nil {
+		panic(err)
+	}
+
+	for _, tag := range tags {
+		if err := s.DB.Exec("INSERT INTO post_tag (post_id, tag_id) VALUES (?, ?)", postID, tag.ID).Error; err != nil {
+			panic(err)
+		}
+	}
+
+	return nil
+}
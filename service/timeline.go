@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"myapp/config"
+	"myapp/middleware"
+	"myapp/model"
+	"myapp/tools"
+	"time"
+)
+
+const (
+	timelineStrategyRead  = "read"
+	timelineStrategyWrite = "write"
+
+	// timelineBackfillSize is how many recent posts of a user are copied into
+	// a new follower's timeline with fan-out-on-write.
+	timelineBackfillSize = 200
+)
+
+// TimelineGet lists the posts of the users the logged in user follows, newest
+// first. With fan-out-on-read the posts are looked up through the follow
+// table, with fan-out-on-write they come from precomputed timeline_entry rows.
+// Either way only posts that would show up in listings are returned.
+func (s *Service) TimelineGet(ctx context.Context, filter model.TimelineFilter) (*model.PostPage, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		posts   []*model.Post
+		column  = "post.publish_at"
+	)
+
+	if filter.Limit <= 0 {
+		filter.Limit = postDefaultLimit
+	} else if filter.Limit > postMaxLimit {
+		filter.Limit = postMaxLimit
+	}
+
+	query := s.DB.Model(&posts).Scopes(postPreload, postPublic).Where("post.deleted_at IS NULL")
+
+	if config.GetTimelineStrategy() == timelineStrategyWrite {
+		column = "timeline_entry.published_at"
+		query = query.Joins("JOIN timeline_entry ON timeline_entry.post_id = post.id AND timeline_entry.user_id = ?", getUser.ID)
+	} else {
+		query = query.Where("post.user_id IN (SELECT followee_id FROM follow WHERE follower_id = ?)", getUser.ID)
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := tools.DecodeCursor(filter.Cursor)
+		if err != nil {
+			panic(err)
+		}
+
+		publishedAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil || cursor.Sort != "publish_at:desc" {
+			panic(tools.NewCustomError(400, "Invalid cursor"))
+		}
+
+		query = query.Where("("+column+" < ? OR ("+column+" = ? AND post.id < ?))", publishedAt, publishedAt, cursor.ID)
+	}
+
+	if err := query.Order(column + " desc").Order("post.id desc").Limit(filter.Limit + 1).Find(&posts).Error; err != nil {
+		panic(err)
+	}
+
+	page := model.PostPage{
+		Posts: posts,
+	}
+
+	if len(posts) > filter.Limit {
+		page.Posts = posts[:filter.Limit]
+		page.HasMore = true
+
+		last := page.Posts[len(page.Posts)-1]
+		page.NextCursor = tools.EncodeCursor(tools.Cursor{
+			Sort:  "publish_at:desc",
+			Value: timelinePublishedAt(last).Format(time.RFC3339Nano),
+			ID:    last.ID,
+		})
+	}
+
+	s.postDecorate(ctx, page.Posts...)
+
+	return &page, nil
+}
+
+// timelineSync fans a post out to its author's followers, or takes it back
+// out of their timelines when it is no longer public. It is a no-op with
+// fan-out-on-read.
+func (s *Service) timelineSync(post *model.Post) {
+	if config.GetTimelineStrategy() != timelineStrategyWrite {
+		return
+	}
+
+	s.timelineRemove(post.ID)
+
+	if post.DeletedAt != nil || post.Status != model.PostStatusPublished || post.Visibility != model.PostVisibilityPublic {
+		return
+	}
+
+	if err := s.DB.Exec(`INSERT INTO timeline_entry (user_id, post_id, author_id, published_at)
+		SELECT follower_id, ?, ?, ? FROM follow WHERE followee_id = ?`,
+		post.ID, post.UserID, timelinePublishedAt(post), post.UserID).Error; err != nil {
+		panic(err)
+	}
+}
+
+func (s *Service) timelineRemove(postID int) {
+	if config.GetTimelineStrategy() != timelineStrategyWrite {
+		return
+	}
+
+	if err := s.DB.Exec("DELETE FROM timeline_entry WHERE post_id = ?", postID).Error; err != nil {
+		panic(err)
+	}
+}
+
+// timelineFollow backfills the recent posts of followeeID into the timeline
+// of a new follower.
+func (s *Service) timelineFollow(followerID int, followeeID int) {
+	if config.GetTimelineStrategy() != timelineStrategyWrite {
+		return
+	}
+
+	s.timelineBackfill(followerID, followeeID)
+}
+
+// timelineBackfill copies the recent posts of followeeID into the timeline of
+// followerID regardless of the configured strategy.
+func (s *Service) timelineBackfill(followerID int, followeeID int) {
+	if err := s.DB.Exec(`INSERT IGNORE INTO timeline_entry (user_id, post_id, author_id, published_at)
+		SELECT ?, post.id, post.user_id, COALESCE(post.publish_at, post.created_at) FROM post
+		WHERE post.user_id = ? AND post.status = ? AND post.visibility = ? AND post.deleted_at IS NULL
+		ORDER BY post.publish_at DESC LIMIT ?`,
+		followerID, followeeID, model.PostStatusPublished, model.PostVisibilityPublic, timelineBackfillSize).Error; err != nil {
+		panic(err)
+	}
+}
+
+func (s *Service) timelineUnfollow(followerID int, followeeID int) {
+	if config.GetTimelineStrategy() != timelineStrategyWrite {
+		return
+	}
+
+	if err := s.DB.Exec("DELETE FROM timeline_entry WHERE user_id = ? AND author_id = ?", followerID, followeeID).Error; err != nil {
+		panic(err)
+	}
+}
+
+// TimelineRebuild refills timeline_entry from the follow table, for
+// switching an existing database over to fan-out-on-write. It runs before the
+// strategy is switched, so it does not look at TIMELINE_STRATEGY.
+func (s *Service) TimelineRebuild(ctx context.Context) (int, error) {
+	var (
+		last  model.Follow
+		count int64
+	)
+
+	if err := s.DB.Exec("DELETE FROM timeline_entry").Error; err != nil {
+		panic(err)
+	}
+
+	for {
+		var follows []*model.Follow
+
+		if err := s.DB.Model(&follows).
+			Where("(follower_id > ? OR (follower_id = ? AND followee_id > ?))", last.FollowerID, last.FollowerID, last.FolloweeID).
+			Order("follower_id").Order("followee_id").
+			Limit(500).
+			Find(&follows).Error; err != nil {
+			panic(err)
+		}
+
+		if len(follows) == 0 {
+			break
+		}
+
+		for _, follow := range follows {
+			s.timelineBackfill(follow.FollowerID, follow.FolloweeID)
+		}
+
+		last = *follows[len(follows)-1]
+	}
+
+	if err := s.DB.Model(&model.TimelineEntry{}).Count(&count).Error; err != nil {
+		panic(err)
+	}
+
+	return int(count), nil
+}
+
+func timelinePublishedAt(post *model.Post) time.Time {
+	if post.PublishAt != nil {
+		return post.PublishAt.UTC()
+	}
+
+	return post.CreatedAt.UTC()
+}
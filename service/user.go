@@ -30,6 +30,10 @@ func (s *Service) UserOnCreate(ctx context.Context, input model.NewUser) (*model
 		panic(fmt.Errorf("email already used"))
 	}
 
+	if input.Handle != "" {
+		input.Handle = s.userCheckHandle(ctx, input.Handle, 0)
+	}
+
 	password, err := tools.HashAndSalt(input.Password)
 	if err != nil {
 		panic(err)
@@ -42,10 +46,15 @@ func (s *Service) UserOnCreate(ctx context.Context, input model.NewUser) (*model
 
 func (s *Service) UserCreate(ctx context.Context, input model.NewUser, password string) (*model.User, error) {
 	user := model.User{
-		Name:      input.Name,
-		Email:     input.Email,
-		Password:  password,
-		CreatedAt: time.Now().UTC(),
+		Name:         input.Name,
+		Email:        input.Email,
+		Password:     password,
+		AvatarStatus: model.AvatarStatusNone,
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	if input.Handle != "" {
+		user.Handle = &input.Handle
 	}
 
 	if err := s.DB.Model(&user).Omit("updated_at").Create(&user).Error; err != nil {
@@ -55,6 +64,51 @@ func (s *Service) UserCreate(ctx context.Context, input model.NewUser, password
 	return &user, nil
 }
 
+// UserSetHandle changes the handle of the logged in user. Posts keep the
+// mentions resolved with the old handle.
+func (s *Service) UserSetHandle(ctx context.Context, input model.UserHandleInput) (*model.User, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+		user    model.User
+	)
+
+	handle := s.userCheckHandle(ctx, input.Handle, getUser.ID)
+
+	if err := s.DB.Model(&user).Where("id = ?", getUser.ID).Updates(map[string]interface{}{
+		"handle":     handle,
+		"updated_at": time.Now().UTC(),
+	}).Error; err != nil {
+		panic(err)
+	}
+
+	return s.UserGetByID(ctx, getUser.ID)
+}
+
+// userCheckHandle normalizes handle and makes sure no user but exceptID has
+// it yet. Deleted users keep their handle so old mentions cannot be taken
+// over.
+func (s *Service) userCheckHandle(ctx context.Context, handle string, exceptID int) string {
+	var (
+		user  model.User
+		count int64
+	)
+
+	handle, ok := tools.NormalizeHandle(handle)
+	if !ok {
+		panic(tools.NewCustomError(400, fmt.Sprintf("Invalid handle, use %d to %d letters, digits or underscores", tools.HandleMinLength, tools.HandleMaxLength)))
+	}
+
+	if err := s.DB.Model(&user).Where("handle = ? AND id <> ?", handle, exceptID).Count(&count).Error; err != nil {
+		panic(err)
+	}
+
+	if count > 0 {
+		panic(tools.NewCustomError(409, "Handle already used"))
+	}
+
+	return handle
+}
+
 func (s *Service) UserLogin(ctx context.Context, input model.UserLogin) (*model.TokenDataResponse, error) {
 	if input.Email == "" || input.Password == "" {
 		panic(fmt.Errorf("invalid email/password"))
@@ -82,40 +136,60 @@ func (s *Service) UserLogin(ctx context.Context, input model.UserLogin) (*model.
 		panic(fmt.Errorf("invalid email/password"))
 	}
 
-	return s.UserCreateAccessAndRefreshToken(ctx, user.ID)
+	tokens, _ := s.UserCreateAccessAndRefreshToken(ctx, user.ID, input.DeviceLabel)
+
+	s.NotificationCreate(ctx, model.NewNotification{
+		UserID:  user.ID,
+		Type:    model.NotificationTypeLogin,
+		Message: "New login to your account from " + clientDescription(middleware.ClientContext(ctx)),
+	})
+
+	return tokens, nil
 }
 
-func (s *Service) UserCreateAccessAndRefreshToken(ctx context.Context, id int) (*model.TokenDataResponse, error) {
-	accessToken, accessExpiredAt := tools.TokenCreate(id)
+// UserLogout revokes the access token of the request and the refresh token
+// it was issued from, with the rest of its token family, ending that session
+// only.
+func (s *Service) UserLogout(ctx context.Context) (string, error) {
+	var (
+		getUser      = middleware.AuthContext(ctx)
+		accessToken  model.AccessTokens
+		refreshToken model.RefreshTokens
+	)
 
-	refreshToken, err := tools.GenerateSecureTokenHex(32)
-	if err != nil {
+	if err := s.DB.Model(&accessToken).Where("token_hash = ? AND user_id = ?", getUser.TokenHash, getUser.ID).Take(&accessToken).Error; err == gorm.ErrRecordNotFound {
+		panic(tools.NewCustomError(401, "Invalid token"))
+	} else if err != nil {
 		panic(err)
 	}
 
-	inputRefreshTokenData := model.RefreshTokens{
-		UserID:    id,
-		TokenHash: tools.HashSHA256(refreshToken),
-		CreatedAt: time.Now().UTC(),
-		ExpiredAt: time.Now().UTC().AddDate(0, 0, 7),
+	if err := s.DB.Model(&refreshToken).Where("id = ?", accessToken.RefreshTokenID).Take(&refreshToken).Error; err != nil {
+		panic(err)
 	}
 
-	refreshTokenData, _ := s.RefreshTokensCreate(ctx, inputRefreshTokenData)
+	s.RefreshTokensRevokeFamily(ctx, refreshToken.FamilyID)
 
-	inputAccessTokenData := model.AccessTokens{
-		UserID:         id,
-		RefreshTokenID: refreshTokenData.ID,
-		TokenHash:      tools.HashSHA256(accessToken),
-		CreatedAt:      time.Now().UTC(),
-		ExpiredAt:      accessExpiredAt,
-	}
+	return "Success", nil
+}
+
+// UserLogoutAll revokes every access and refresh token of the logged in
+// user, on all devices.
+func (s *Service) UserLogoutAll(ctx context.Context) (string, error) {
+	var (
+		getUser = middleware.AuthContext(ctx)
+	)
+
+	s.RefreshTokensRevokeByUserID(ctx, getUser.ID)
+
+	return "Success", nil
+}
 
-	s.AccessTokensCreate(ctx, inputAccessTokenData)
+// UserCreateAccessAndRefreshToken starts a new session, its refresh token
+// begins a token family of its own.
+func (s *Service) UserCreateAccessAndRefreshToken(ctx context.Context, id int, deviceLabel string) (*model.TokenDataResponse, error) {
+	_, tokens := s.refreshTokensIssue(ctx, id, 0, deviceLabel)
 
-	return &model.TokenDataResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	}, nil
+	return tokens, nil
 }
 
 func (s *Service) UserGetMe(ctx context.Context) (*model.User, error) {
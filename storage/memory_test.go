@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		run    func(m *Memory) error
+		key    string
+		exists bool
+		body   string
+	}{
+		{
+			name: "missing key",
+			run:  func(m *Memory) error { return nil },
+			key:  "a",
+		},
+		{
+			name: "put",
+			run: func(m *Memory) error {
+				return m.Put(ctx, "a", strings.NewReader("one"), 3, "text/plain")
+			},
+			key:    "a",
+			exists: true,
+			body:   "one",
+		},
+		{
+			name: "put overwrites",
+			run: func(m *Memory) error {
+				if err := m.Put(ctx, "a", strings.NewReader("one"), 3, "text/plain"); err != nil {
+					return err
+				}
+				return m.Put(ctx, "a", strings.NewReader("two"), 3, "text/plain")
+			},
+			key:    "a",
+			exists: true,
+			body:   "two",
+		},
+		{
+			name: "delete",
+			run: func(m *Memory) error {
+				if err := m.Put(ctx, "a", strings.NewReader("one"), 3, "text/plain"); err != nil {
+					return err
+				}
+				return m.Delete(ctx, "a")
+			},
+			key: "a",
+		},
+		{
+			name: "delete missing key",
+			run:  func(m *Memory) error { return m.Delete(ctx, "a") },
+			key:  "a",
+		},
+		{
+			name: "keys are separate",
+			run: func(m *Memory) error {
+				return m.Put(ctx, "a", strings.NewReader("one"), 3, "text/plain")
+			},
+			key: "b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory()
+			if err := tt.run(m); err != nil {
+				t.Fatal(err)
+			}
+
+			exists, err := m.Exists(ctx, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exists != tt.exists {
+				t.Fatalf("Exists = %v, want %v", exists, tt.exists)
+			}
+
+			body, err := m.Get(ctx, tt.key)
+			if !tt.exists {
+				if err != ErrNotFound {
+					t.Fatalf("Get error = %v, want ErrNotFound", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer body.Close()
+
+			data, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.body {
+				t.Fatalf("Get = %q, want %q", data, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"myapp/config"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is an in-process S3 holding objects by path. It refuses requests
+// whose SigV4 signature does not check out, computed here independently of
+// S3.sign.
+type fakeS3 struct {
+	cfg      config.S3Config
+	rejected []string
+	mu       sync.Mutex
+	objects  map[string][]byte
+	types    map[string]string
+	fail     bool
+}
+
+var s3AuthorizationPattern = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=([^/]+)/(\d{8})/([^/]+)/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=([0-9a-f]{64})$`)
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.fail {
+		http.Error(w, "<Error>InternalError</Error>", http.StatusInternalServerError)
+		return
+	}
+
+	if reason := f.checkSignature(r); reason != "" {
+		f.mu.Lock()
+		f.rejected = append(f.rejected, r.Method+" "+r.URL.Path+": "+reason)
+		f.mu.Unlock()
+		http.Error(w, "<Error>SignatureDoesNotMatch</Error>", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := r.URL.Path
+	data, ok := f.objects[path]
+
+	switch r.Method {
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.objects[path] = body
+		f.types[path] = r.Header.Get("Content-Type")
+	case http.MethodGet, http.MethodHead:
+		if !ok {
+			http.Error(w, "<Error>NoSuchKey</Error>", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", f.types[path])
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case http.MethodDelete:
+		if !ok {
+			http.Error(w, "<Error>NoSuchKey</Error>", http.StatusNotFound)
+			return
+		}
+		delete(f.objects, path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) checkSignature(r *http.Request) string {
+	amzDate := r.Header.Get("X-Amz-Date")
+	if _, err := time.Parse("20060102T150405Z", amzDate); err != nil {
+		return fmt.Sprintf("bad x-amz-date %q", amzDate)
+	}
+
+	if payload := r.Header.Get("X-Amz-Content-Sha256"); payload != "UNSIGNED-PAYLOAD" {
+		return fmt.Sprintf("x-amz-content-sha256 = %q", payload)
+	}
+
+	match := s3AuthorizationPattern.FindStringSubmatch(r.Header.Get("Authorization"))
+	if match == nil {
+		return fmt.Sprintf("bad Authorization %q", r.Header.Get("Authorization"))
+	}
+
+	accessKey, date, region, signature := match[1], match[2], match[3], match[4]
+	if accessKey != f.cfg.AccessKey || region != f.cfg.Region || date != amzDate[:8] {
+		return fmt.Sprintf("credential %s/%s/%s does not match", accessKey, date, region)
+	}
+
+	canonical := r.Method + "\n" +
+		r.URL.EscapedPath() + "\n" +
+		r.URL.RawQuery + "\n" +
+		"host:" + r.Host + "\n" +
+		"x-amz-content-sha256:UNSIGNED-PAYLOAD\n" +
+		"x-amz-date:" + amzDate + "\n" +
+		"\n" +
+		"host;x-amz-content-sha256;x-amz-date\n" +
+		"UNSIGNED-PAYLOAD"
+
+	sum := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + date + "/" + region + "/s3/aws4_request\n" + hex.EncodeToString(sum[:])
+
+	key := []byte("AWS4" + f.cfg.SecretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+
+	if hex.EncodeToString(key) != signature {
+		return "signature does not match"
+	}
+
+	return ""
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *S3) {
+	fake := &fakeS3{
+		cfg: config.S3Config{
+			Region:    "eu-west-1",
+			Bucket:    "uploads",
+			AccessKey: "AKIDEXAMPLE",
+			SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		},
+		objects: map[string][]byte{},
+		types:   map[string]string{},
+	}
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	cfg := fake.cfg
+	cfg.Endpoint = server.URL + "/"
+
+	return fake, NewS3(cfg)
+}
+
+func TestS3(t *testing.T) {
+	ctx := context.Background()
+
+	keys := []string{
+		"attachments/ab/abcdef",
+		"avatars/originals/1-a b+c=d.png",
+	}
+
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			fake, s3 := newFakeS3(t)
+
+			exists, err := s3.Exists(ctx, key)
+			if err != nil || exists {
+				t.Fatalf("Exists before Put = %v, %v, want false", exists, err)
+			}
+
+			if _, err := s3.Get(ctx, key); err != ErrNotFound {
+				t.Fatalf("Get before Put error = %v, want ErrNotFound", err)
+			}
+
+			if err := s3.Put(ctx, key, strings.NewReader("hello"), 5, "text/plain"); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := fake.types["/uploads/"+key]; got != "text/plain" {
+				t.Fatalf("stored content type %q, want text/plain", got)
+			}
+
+			exists, err = s3.Exists(ctx, key)
+			if err != nil || !exists {
+				t.Fatalf("Exists after Put = %v, %v, want true", exists, err)
+			}
+
+			body, err := s3.Get(ctx, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _ := io.ReadAll(body)
+			body.Close()
+			if string(data) != "hello" {
+				t.Fatalf("Get = %q, want %q", data, "hello")
+			}
+
+			if err := s3.Delete(ctx, key); err != nil {
+				t.Fatal(err)
+			}
+
+			if exists, _ := s3.Exists(ctx, key); exists {
+				t.Fatal("object still exists after Delete")
+			}
+
+			// A 404 on delete is what was wanted anyway.
+			if err := s3.Delete(ctx, key); err != nil {
+				t.Fatalf("Delete of missing object error = %v, want nil", err)
+			}
+
+			if len(fake.rejected) > 0 {
+				t.Fatalf("fake rejected requests: %v", fake.rejected)
+			}
+		})
+	}
+}
+
+func TestS3ServerError(t *testing.T) {
+	ctx := context.Background()
+
+	fake, s3 := newFakeS3(t)
+	fake.fail = true
+
+	if err := s3.Put(ctx, "key", strings.NewReader("x"), 1, "text/plain"); err == nil || err == ErrNotFound {
+		t.Fatalf("Put error = %v, want a server error", err)
+	}
+
+	if _, err := s3.Exists(ctx, "key"); err == nil || err == ErrNotFound {
+		t.Fatalf("Exists error = %v, want a server error", err)
+	}
+}
+
+func TestS3WrongSecret(t *testing.T) {
+	fake, s3 := newFakeS3(t)
+	s3.config.SecretKey = "not the secret"
+
+	if err := s3.Put(context.Background(), "key", strings.NewReader("x"), 1, "text/plain"); err == nil {
+		t.Fatal("Put signed with the wrong secret succeeded")
+	}
+
+	if len(fake.rejected) != 1 || !strings.HasSuffix(fake.rejected[0], "signature does not match") {
+		t.Fatalf("rejected = %v, want one signature mismatch", fake.rejected)
+	}
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"myapp/config"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Storage keeps uploaded files as objects addressed by key.
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var storage Storage
+
+// Init picks the configured storage backend.
+func Init() {
+	switch config.GetStorageBackend() {
+	case "local":
+		storage = NewLocal(config.GetStorageLocalDir())
+	case "s3":
+		storage = NewS3(config.GetS3Config())
+	case "memory":
+		storage = NewMemory()
+	default:
+		panic("unknown STORAGE_BACKEND " + config.GetStorageBackend())
+	}
+}
+
+func GetStorage() Storage {
+	return storage
+}
+
+// SetStorage replaces the backend, e.g. with NewMemory in tests.
+func SetStorage(s Storage) {
+	storage = s
+}
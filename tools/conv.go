@@ -1,14 +1,31 @@
 package tools
 
-import "myapp/model"
+import (
+	"fmt"
+	"myapp/model"
+)
 
 func UserToUserData(input model.User) *model.UserData {
 	return &model.UserData{
-		ID:        input.ID,
-		Name:      input.Name,
-		Email:     input.Email,
-		CreatedAt: input.CreatedAt,
-		UpdatedAt: input.UpdatedAt,
-		DeletedAt: input.DeletedAt,
+		ID:           input.ID,
+		Name:         input.Name,
+		Handle:       input.Handle,
+		Email:        input.Email,
+		AvatarStatus: input.AvatarStatus,
+		AvatarURL:    UserAvatarURL(input.ID, input.AvatarHash),
+		CreatedAt:    input.CreatedAt,
+		UpdatedAt:    input.UpdatedAt,
+		DeletedAt:    input.DeletedAt,
 	}
 }
+
+// UserAvatarURL points at the avatar endpoint. Processed avatars get their
+// hash as a version so the URL changes, and caches refresh, on every upload.
+func UserAvatarURL(id int, hash *string) string {
+	url := fmt.Sprintf("/users/%d/avatar", id)
+	if hash != nil {
+		url += "?v=" + (*hash)[:12]
+	}
+
+	return url
+}
@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// Cursor is the keyset position of the last row of a page. It is handed to
+// clients as an opaque string.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"i"`
+}
+
+func EncodeCursor(cursor Cursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeCursor(input string) (*Cursor, error) {
+	var (
+		cursor Cursor
+	)
+
+	raw, err := base64.RawURLEncoding.DecodeString(input)
+	if err != nil {
+		return nil, NewCustomError(400, "Invalid cursor")
+	}
+
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, NewCustomError(400, "Invalid cursor")
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{"id only", Cursor{Sort: "id:desc", ID: 42}},
+		{"time value", Cursor{Sort: "created_at:desc", Value: "2024-05-01T10:00:00.123456Z", ID: 7}},
+		{"empty", Cursor{}},
+		{"unicode value", Cursor{Sort: "title:asc", Value: "héllo / wörld?&=", ID: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := DecodeCursor(EncodeCursor(tt.cursor))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if *decoded != tt.cursor {
+				t.Fatalf("decoded %+v, want %+v", *decoded, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"not base64", "!!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"i":1}`))},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("cursor"))},
+		{"wrong type", base64.RawURLEncoding.EncodeToString([]byte(`{"i":"1"}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeCursor(tt.input)
+
+			customErr, ok := err.(*CustomError)
+			if !ok || customErr.Code != 400 {
+				t.Fatalf("error = %v, want a 400 CustomError", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// UnifiedDiff returns a line based diff of a and b in unified format with
+// three lines of context. It returns an empty string when a equals b.
+func UnifiedDiff(fromName string, toName string, a string, b string) string {
+	if a == b {
+		return ""
+	}
+
+	ops := diffLines(strings.Split(a, "\n"), strings.Split(b, "\n"))
+
+	var out strings.Builder
+	out.WriteString("--- " + fromName + "\n")
+	out.WriteString("+++ " + toName + "\n")
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Grow the hunk until the gap between two changes exceeds twice the
+		// context size.
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+
+			gap := end
+			for gap < len(ops) && ops[gap].kind == ' ' {
+				gap++
+			}
+
+			if gap == len(ops) || gap-end > 2*diffContext {
+				end += diffContext
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = gap
+		}
+
+		aStart, bStart := 1, 1
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				aStart++
+			}
+			if op.kind != '-' {
+				bStart++
+			}
+		}
+
+		aLen, bLen := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+		}
+
+		if aLen == 0 {
+			aStart--
+		}
+		if bLen == 0 {
+			bStart--
+		}
+
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			out.WriteByte('\n')
+		}
+
+		i = end
+	}
+
+	return out.String()
+}
+
+// diffLines computes an edit script from the longest common subsequence of
+// a and b, after trimming their common prefix and suffix. The subsequence is
+// found with Hirschberg's algorithm so memory stays linear in the input; when
+// the changed middle would take more than diffMaxCells comparisons it is
+// reported as removed and added as a whole instead.
+func diffLines(a []string, b []string) []diffOp {
+	var ops []diffOp
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+
+	if len(midA) > 0 && len(midB) > diffMaxCells/len(midA) {
+		ops = diffReplace(ops, midA, midB)
+	} else {
+		ops = diffHirschberg(ops, midA, midB)
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	return ops
+}
+
+// diffMaxCells caps the line comparisons of one diff, about a few hundred
+// milliseconds of work.
+const diffMaxCells = 16 << 20
+
+// diffHirschberg appends the edit script turning a into b to ops. a is split
+// in half and b where the LCS of both halves is longest, each part is then
+// diffed on its own.
+func diffHirschberg(ops []diffOp, a []string, b []string) []diffOp {
+	if len(a) == 0 || len(b) == 0 {
+		return diffReplace(ops, a, b)
+	}
+
+	if len(a) == 1 {
+		for j, line := range b {
+			if line == a[0] {
+				ops = diffReplace(ops, nil, b[:j])
+				ops = append(ops, diffOp{' ', line})
+				return diffReplace(ops, nil, b[j+1:])
+			}
+		}
+
+		return diffReplace(ops, a, b)
+	}
+
+	mid := len(a) / 2
+	head := lcsHead(a[:mid], b)
+	tail := lcsTail(a[mid:], b)
+
+	split := 0
+	for k := range head {
+		if head[k]+tail[k] > head[split]+tail[split] {
+			split = k
+		}
+	}
+
+	ops = diffHirschberg(ops, a[:mid], b[:split])
+	return diffHirschberg(ops, a[mid:], b[split:])
+}
+
+// diffReplace appends every line of a as removed and every line of b as
+// added.
+func diffReplace(ops []diffOp, a []string, b []string) []diffOp {
+	for _, line := range a {
+		ops = append(ops, diffOp{'-', line})
+	}
+
+	for _, line := range b {
+		ops = append(ops, diffOp{'+', line})
+	}
+
+	return ops
+}
+
+// lcsHead returns, for every k, the length of the LCS of a and b[:k].
+func lcsHead(a []string, b []string) []int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev
+}
+
+// lcsTail returns, for every k, the length of the LCS of a and b[k:].
+func lcsTail(a []string, b []string) []int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				cur[j] = prev[j+1] + 1
+			} else {
+				cur[j] = max(prev[j], cur[j+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev
+}
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    "one\ntwo",
+			b:    "one\ntwo",
+			want: "",
+		},
+		{
+			name: "changed line",
+			a:    "one\ntwo\nthree",
+			b:    "one\n2\nthree",
+			want: "--- a\n+++ b\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n",
+		},
+		{
+			name: "added line",
+			a:    "one\nthree",
+			b:    "one\ntwo\nthree",
+			want: "--- a\n+++ b\n@@ -1,2 +1,3 @@\n one\n+two\n three\n",
+		},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "one",
+			want: "--- a\n+++ b\n@@ -1,1 +1,1 @@\n-\n+one\n",
+		},
+		{
+			name: "context is trimmed",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9",
+			b:    "1\n2\n3\n4\nfive\n6\n7\n8\n9",
+			want: "--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "distant changes get separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11",
+			b:    "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\neleven",
+			want: "--- a\n+++ b\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -8,4 +8,4 @@\n 8\n 9\n 10\n-11\n+eleven\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnifiedDiff("a", "b", tt.a, tt.b); got != tt.want {
+				t.Fatalf("UnifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		kept int
+	}{
+		{"empty", "", "", 0},
+		{"all removed", "a b c", "", 0},
+		{"all added", "", "a b c", 0},
+		{"reordered", "a b c d", "b a d c", 2},
+		{"interleaved", "a x b y c", "a b c", 3},
+		{"repeated lines", "a a b a a", "a b a b a", 4},
+		{"nothing in common", "a b c", "x y z", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+			ops := diffLines(a, b)
+
+			var gotA, gotB []string
+			kept := 0
+			for _, op := range ops {
+				if op.kind != '+' {
+					gotA = append(gotA, op.line)
+				}
+				if op.kind != '-' {
+					gotB = append(gotB, op.line)
+				}
+				if op.kind == ' ' {
+					kept++
+				}
+			}
+
+			if strings.Join(gotA, " ") != tt.a || strings.Join(gotB, " ") != tt.b {
+				t.Fatalf("script %v does not turn %q into %q", ops, tt.a, tt.b)
+			}
+
+			if kept != tt.kept {
+				t.Fatalf("kept %d lines, want the %d of the longest common subsequence", kept, tt.kept)
+			}
+		})
+	}
+}
+
+func TestDiffLinesOverCap(t *testing.T) {
+	// Reversed lines share a subsequence, but finding it would take more
+	// than diffMaxCells comparisons.
+	var a, b []string
+	for i := 0; i < 5000; i++ {
+		a = append(a, strconv.Itoa(i))
+		b = append(b, strconv.Itoa(4999-i))
+	}
+
+	ops := diffLines(a, b)
+	if len(ops) != len(a)+len(b) {
+		t.Fatalf("got %d ops, want every line removed and added", len(ops))
+	}
+
+	for i, op := range ops {
+		want := byte('-')
+		if i >= len(a) {
+			want = '+'
+		}
+		if op.kind != want {
+			t.Fatalf("op %d is %q, want %q", i, op.kind, want)
+		}
+	}
+}
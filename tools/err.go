@@ -5,6 +5,9 @@ import "net/http"
 type CustomError struct {
 	Code    int
 	Message string
+	// Data is an optional payload for the response, such as the current
+	// state of a resource on 412.
+	Data interface{}
 }
 
 func (e *CustomError) Error() string {
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PostETag is the strong entity tag of version of post id, the version
+// changes with every write.
+func PostETag(id int, version int) string {
+	return fmt.Sprintf(`"post-%d-v%d"`, id, version)
+}
+
+// ParseETagList splits an If-Match or If-None-Match header into its entity
+// tags. An empty header gives nil.
+func ParseETagList(header string) []string {
+	var tags []string
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// ETagMatch reports whether etag satisfies an If-Match list. If-Match uses
+// strong comparison, so weak tags never match.
+func ETagMatch(tags []string, etag string) bool {
+	for _, tag := range tags {
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ETagMatchWeak reports whether etag satisfies an If-None-Match list, which
+// uses weak comparison: W/ prefixes are ignored on both sides.
+func ETagMatchWeak(tags []string, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, tag := range tags {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
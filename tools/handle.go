@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	HandleMinLength = 3
+	HandleMaxLength = 30
+)
+
+func isHandleByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '_'
+}
+
+// NormalizeHandle lowercases handle and reports whether it is valid: 3 to 30
+// ASCII letters, digits or underscores.
+func NormalizeHandle(handle string) (string, bool) {
+	handle = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+
+	if len(handle) < HandleMinLength || len(handle) > HandleMaxLength {
+		return handle, false
+	}
+
+	for i := 0; i < len(handle); i++ {
+		if !isHandleByte(handle[i]) {
+			return handle, false
+		}
+	}
+
+	return handle, true
+}
+
+// ParseMentions returns the distinct handles mentioned as @handle in content,
+// lowercased, in order of first appearance. An @ preceded by a handle
+// character, as in an email address, is not a mention, nor is a handle
+// directly followed by a non ASCII letter or digit.
+func ParseMentions(content string) []string {
+	var (
+		handles []string
+		seen    = map[string]bool{}
+	)
+
+	for i := 0; i < len(content); i++ {
+		if content[i] != '@' || (i > 0 && (isHandleByte(content[i-1]) || content[i-1] == '@')) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(content) && isHandleByte(content[end]) {
+			end++
+		}
+
+		// A handle cut short by a letter or digit it cannot hold, as in
+		// "@alicé", is not a mention.
+		next, _ := utf8.DecodeRuneInString(content[end:])
+		if unicode.IsLetter(next) || unicode.IsDigit(next) {
+			i = end - 1
+			continue
+		}
+
+		if handle, ok := NormalizeHandle(content[i+1 : end]); ok && !seen[handle] {
+			seen[handle] = true
+			handles = append(handles, handle)
+		}
+
+		i = end - 1
+	}
+
+	return handles
+}
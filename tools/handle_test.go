@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"none", "no mentions here", nil},
+		{"one", "hello @alice", []string{"alice"}},
+		{"start of content", "@bob hi", []string{"bob"}},
+		{"lowercased", "thanks @Alice_01", []string{"alice_01"}},
+		{"order of first appearance", "@carol @alice @bob", []string{"carol", "alice", "bob"}},
+		{"distinct", "@alice and @ALICE again", []string{"alice"}},
+		{"punctuation ends handle", "(@alice), @bob!", []string{"alice", "bob"}},
+		{"email is not a mention", "mail me at bob@example.com", nil},
+		{"double at is not a mention", "@@alice", nil},
+		{"too short", "@ab", nil},
+		{"too long", "@" + strings.Repeat("a", HandleMaxLength+1), nil},
+		{"longest", "@" + strings.Repeat("a", HandleMaxLength), []string{strings.Repeat("a", HandleMaxLength)}},
+		{"bare at", "@ alone @", nil},
+		{"non ascii letter is not a handle end", "@alicé", nil},
+		{"non ascii punctuation ends handle", "@alice…", []string{"alice"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseMentions(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseMentions(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
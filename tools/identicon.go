@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+const identiconGrid = 5
+
+// Identicon draws a GitHub style 5x5 mirrored pattern derived from seed, so
+// the same seed always gives the same PNG.
+func Identicon(seed string, size int) []byte {
+	sum := sha256.Sum256([]byte(seed))
+
+	fg := color.NRGBA{R: sum[0], G: sum[1], B: sum[2], A: 255}
+	bg := color.NRGBA{R: 240, G: 240, B: 240, A: 255}
+
+	var cells [identiconGrid][identiconGrid]bool
+	for row := 0; row < identiconGrid; row++ {
+		for col := 0; col < (identiconGrid+1)/2; col++ {
+			on := sum[3+row*3+col]%2 == 0
+			cells[row][col] = on
+			cells[row][identiconGrid-1-col] = on
+		}
+	}
+
+	// Half a cell of padding on every side.
+	cell := float64(size) / (identiconGrid + 1)
+	pad := cell / 2
+
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			col := int((float64(x) - pad) / cell)
+			row := int((float64(y) - pad) / cell)
+
+			c := bg
+			if float64(x) >= pad && float64(y) >= pad && col < identiconGrid && row < identiconGrid && cells[row][col] {
+				c = fg
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+
+	return buf.Bytes()
+}
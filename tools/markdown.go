@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	htmlPolicy = newHTMLPolicy()
+)
+
+// newHTMLPolicy allows the usual user generated content markup plus code
+// block languages, and drops scripts, styles, event handlers and javascript:
+// URLs.
+func newHTMLPolicy() *bluemonday.Policy {
+	policy := bluemonday.UGCPolicy()
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w-]+$`)).OnElements("code")
+
+	return policy
+}
+
+// MarkdownToHTML renders markdown and sanitizes the result.
+func MarkdownToHTML(input string) (string, error) {
+	var buf bytes.Buffer
+
+	if err := markdown.Convert([]byte(input), &buf); err != nil {
+		return "", err
+	}
+
+	return htmlPolicy.Sanitize(buf.String()), nil
+}
+
+// PlainToHTML escapes text and turns blank line separated blocks into
+// paragraphs, keeping single line breaks.
+func PlainToHTML(input string) string {
+	var b strings.Builder
+
+	input = strings.ReplaceAll(input, "\r\n", "\n")
+
+	for _, block := range strings.Split(input, "\n\n") {
+		block = strings.Trim(block, "\n")
+		if block == "" {
+			continue
+		}
+
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(block), "\n", "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+
+	return b.String()
+}
@@ -1,7 +1,33 @@
 package tools
 
-import "gorm.io/gorm"
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 func IsDeletedAtNull(query *gorm.DB) *gorm.DB {
 	return query.Where("deleted_at IS NULL")
 }
+
+// IsAccessTokenActive keeps access tokens that have not expired and are not
+// revoked, neither themselves nor through the refresh token they were issued
+// from. A refresh token replaced by rotation leaves its access tokens usable
+// until they expire.
+func IsAccessTokenActive(query *gorm.DB) *gorm.DB {
+	return query.
+		Where("access_tokens.expired_at > ? AND access_tokens.revoked_at IS NULL", time.Now().UTC()).
+		Where("EXISTS (SELECT 1 FROM refresh_tokens WHERE refresh_tokens.id = access_tokens.refresh_token_id AND (refresh_tokens.revoked_at IS NULL OR refresh_tokens.replaced_by_id IS NOT NULL))")
+}
+
+// IsRefreshTokenActive keeps refresh tokens that have not expired and are not
+// revoked.
+func IsRefreshTokenActive(query *gorm.DB) *gorm.DB {
+	return query.Where("refresh_tokens.expired_at > ? AND refresh_tokens.revoked_at IS NULL", time.Now().UTC())
+}
+
+// EscapeLike escapes LIKE wildcards so input is matched literally.
+func EscapeLike(input string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(input)
+}
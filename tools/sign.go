@@ -0,0 +1,18 @@
+package tools
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+func SignHMAC(key string, message string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifyHMAC(key string, message string, signature string) bool {
+	return hmac.Equal([]byte(SignHMAC(key, message)), []byte(signature))
+}
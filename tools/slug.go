@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// transliterations covers letters that do not decompose into an ASCII base
+// letter plus combining marks.
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'ł': "l", 'þ': "th", 'ı': "i",
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z",
+	'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+	'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i",
+	'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s",
+	'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// Slugify lowercases input and joins its runs of letters and digits with
+// single dashes.
+func Slugify(input string) string {
+	var (
+		b       strings.Builder
+		pending bool
+	)
+
+	for _, r := range strings.ToLower(input) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pending && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pending = false
+			b.WriteRune(r)
+			continue
+		}
+
+		pending = true
+	}
+
+	return b.String()
+}
+
+// Transliterate lowercases input and approximates it in ASCII. Accents are
+// stripped, Cyrillic and Greek are romanized and any other non-ASCII letter
+// is dropped.
+func Transliterate(input string) string {
+	var b strings.Builder
+
+	for _, r := range norm.NFD.String(strings.ToLower(input)) {
+		switch {
+		case r < unicode.MaxASCII:
+			b.WriteRune(r)
+		case unicode.Is(unicode.Mn, r):
+			// combining mark left over from the decomposition
+		default:
+			if ascii, ok := transliterations[r]; ok {
+				b.WriteString(ascii)
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// SlugifyASCII builds an ASCII only slug of at most maxLen bytes, cut on a
+// word boundary when possible.
+func SlugifyASCII(input string, maxLen int) string {
+	slug := Slugify(Transliterate(input))
+
+	if len(slug) > maxLen {
+		slug = slug[:maxLen]
+		if i := strings.LastIndexByte(slug, '-'); i > 0 {
+			slug = slug[:i]
+		}
+	}
+
+	return strings.Trim(slug, "-")
+}
@@ -0,0 +1,61 @@
+package tools
+
+import "strings"
+
+// userAgentBrowsers is checked in order, browsers that also name the one
+// they are built on come first.
+var userAgentBrowsers = []struct{ token, name string }{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+}
+
+var userAgentSystems = []struct{ token, name string }{
+	{"Windows", "Windows"},
+	{"Android", "Android"},
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"CrOS", "ChromeOS"},
+	{"Mac OS X", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// DeviceLabel names the device behind a user agent for a session list, like
+// "Firefox on Windows". Non browser clients are named by their product, e.g.
+// "curl".
+func DeviceLabel(userAgent string) string {
+	var browser, system string
+
+	for _, b := range userAgentBrowsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	for _, s := range userAgentSystems {
+		if strings.Contains(userAgent, s.token) {
+			system = s.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+
+	product, _, _ := strings.Cut(strings.TrimSpace(userAgent), "/")
+	if product, _, _ = strings.Cut(product, " "); product != "" {
+		return product
+	}
+
+	return "Unknown device"
+}